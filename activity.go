@@ -0,0 +1,122 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ItemActionSet describes the actions of an activity. Only the facets
+// of the actions that took place are present.
+type ItemActionSet struct {
+	Comment *struct{} `json:"comment,omitempty"`
+	Create  *struct{} `json:"create,omitempty"`
+	Delete  *struct{} `json:"delete,omitempty"`
+	Edit    *struct{} `json:"edit,omitempty"`
+	Mention *struct{} `json:"mention,omitempty"`
+	Move    *struct{} `json:"move,omitempty"`
+	Rename  *struct{} `json:"rename,omitempty"`
+	Restore *struct{} `json:"restore,omitempty"`
+	Share   *struct{} `json:"share,omitempty"`
+	Version *struct{} `json:"version,omitempty"`
+}
+
+type ItemActivityTimeSet struct {
+	// When the activity was observed to take place.
+	ObservedDateTime string `json:"observedDateTime,omitempty"`
+
+	// When the observation was recorded on the service.
+	RecordedDateTime string `json:"recordedDateTime,omitempty"`
+}
+
+// ItemActivity describes an activity that took place on an item or within a container.
+type ItemActivity struct {
+	// The unique identifier of the activity. Read-only.
+	Id string `json:"id,omitempty"`
+
+	// The actions that took place.
+	Action *ItemActionSet `json:"action,omitempty"`
+
+	// Identity of who performed the action.
+	Actor *IdentitySet `json:"actor,omitempty"`
+
+	// Details about when the activity took place.
+	Times ItemActivityTimeSet `json:"times,omitempty"`
+
+	// The item the activity refers to.
+	DriveItem *DriveItem `json:"driveItem,omitempty"`
+}
+
+// ActivityOptions limits the activities returned by GetDriveActivities.
+type ActivityOptions struct {
+	// Only return activities recorded after Since, if not zero.
+	Since time.Time
+
+	// Maximum number of activities to return, or zero for all.
+	Top int
+}
+
+// GetDriveActivities retrieves the recent activities in the drive, most recent first,
+// including the item each activity refers to. Since is applied by the client.
+// The drive activity feed is only available on the Graph beta endpoint.
+func (c *OneDriveClient) GetDriveActivities(ctx context.Context, opts ActivityOptions) (activities []ItemActivity, err error) {
+	query := url.Values{}
+	query.Set("$expand", "driveItem")
+	if opts.Top > 0 {
+		query.Set("$top", strconv.Itoa(opts.Top))
+	}
+	link := c.betaURL(c.drive() + "/activities?" + query.Encode())
+
+	for link != "" {
+		body, err := c.get(ctx, link)
+		if err != nil {
+			return nil, err
+		}
+
+		var page struct {
+			Value    []ItemActivity `json:"value"`
+			NextLink string         `json:"@odata.nextLink,omitempty"`
+		}
+		err = c.unmarshal(body, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, activity := range page.Value {
+			if !opts.Since.IsZero() {
+				recorded, err := time.Parse(time.RFC3339, activity.Times.RecordedDateTime)
+				if err == nil && !recorded.After(opts.Since) {
+					// activities are in descending order, so no newer ones follow
+					return activities, nil
+				}
+			}
+
+			activities = append(activities, activity)
+			if opts.Top > 0 && len(activities) == opts.Top {
+				return activities, nil
+			}
+		}
+
+		link = page.NextLink
+	}
+
+	return activities, nil
+}
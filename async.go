@@ -0,0 +1,146 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	// defaultPollInterval is the default first interval between operation status polls.
+	defaultPollInterval = time.Second
+
+	// defaultMaxPollInterval is the default limit of the interval between polls.
+	defaultMaxPollInterval = 30 * time.Second
+)
+
+// ErrOperationFailed is returned by PollOperation for an operation that failed.
+var ErrOperationFailed = errors.New("operation failed")
+
+// AsyncOperationStatus is the status of a long-running operation.
+type AsyncOperationStatus struct {
+	// Type of the operation, e.g., itemCopy.
+	Operation string `json:"operation,omitempty"`
+
+	// Percentage of the operation that is complete.
+	PercentageComplete float64 `json:"percentageComplete,omitempty"`
+
+	// ID of the resource created by the operation, once it has completed.
+	ResourceId string `json:"resourceId,omitempty"`
+
+	// Status of the operation, e.g., notStarted, inProgress, completed, or failed.
+	Status string `json:"status,omitempty"`
+
+	// Description of the status.
+	StatusDescription string `json:"statusDescription,omitempty"`
+
+	// Error of a failed operation, if any.
+	Error *Err `json:"error,omitempty"`
+}
+
+// WithPreferAsync asks Graph to run a long-running operation asynchronously.
+// Graph then responds with 202 Accepted and the URL of a monitor in the Location
+// header, which can be passed to PollOperation.
+func WithPreferAsync() RequestOption {
+	return func(req *http.Request) {
+		req.Header.Add("Prefer", "respond-async")
+	}
+}
+
+// PollOperation polls the monitor at monitorURL until the operation has completed
+// and returns the resulting item, or until the operation fails or ctx is done.
+// The interval between polls starts at one second and doubles up to 30 seconds,
+// unless configured with WithPollInterval.
+func (c *OneDriveClient) PollOperation(ctx context.Context, monitorURL string) (driveItem DriveItem, err error) {
+	status, err := c.pollOperation(ctx, monitorURL)
+	if err != nil {
+		return DriveItem{}, err
+	}
+
+	return c.GetItemByID(ctx, status.ResourceId)
+}
+
+// pollOperation polls the monitor at monitorURL until the operation has completed
+// and returns the final status, or until the operation fails or ctx is done.
+func (c *OneDriveClient) pollOperation(ctx context.Context, monitorURL string) (status AsyncOperationStatus, err error) {
+	interval, maxInterval := c.pollInterval, c.maxPollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	if maxInterval <= 0 {
+		maxInterval = defaultMaxPollInterval
+	}
+	if maxInterval < interval {
+		maxInterval = interval
+	}
+
+	for {
+		status, err := c.operationStatus(ctx, monitorURL)
+		if err != nil {
+			return AsyncOperationStatus{}, err
+		}
+
+		switch status.Status {
+		case "completed":
+			return status, nil
+		case "failed":
+			desc := status.StatusDescription
+			if desc == "" && status.Error != nil {
+				desc = status.Error.Message
+			}
+			return AsyncOperationStatus{}, fmt.Errorf("%w: %s", ErrOperationFailed, desc)
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return AsyncOperationStatus{}, ctx.Err()
+		case <-timer.C:
+		}
+
+		interval *= 2
+		if interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+}
+
+// operationStatus retrieves the status of the operation at monitorURL.
+// The monitor URL is pre-authenticated, so it is requested without the token.
+func (c *OneDriveClient) operationStatus(ctx context.Context, monitorURL string) (status AsyncOperationStatus, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, monitorURL, nil)
+	if err != nil {
+		return AsyncOperationStatus{}, err
+	}
+
+	var body bytes.Buffer
+	_, err = c.sendVia(c.uploadClient, req, &body)
+	if err != nil {
+		return AsyncOperationStatus{}, err
+	}
+
+	err = c.unmarshal(body.Bytes(), &status)
+
+	return status, err
+}
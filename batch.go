@@ -0,0 +1,103 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// maxBatchRequests is the maximum number of requests in a JSON batch.
+const maxBatchRequests = 20
+
+// batchRequest is an individual request within a JSON batch.
+type batchRequest struct {
+	Id        string            `json:"id"`
+	Method    string            `json:"method"`
+	URL       string            `json:"url"` // relative to the Graph version, e.g., /me/drive
+	Body      interface{}       `json:"body,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	DependsOn []string          `json:"dependsOn,omitempty"`
+}
+
+// batchResponse is an individual response within a JSON batch.
+type batchResponse struct {
+	Id      string            `json:"id"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// header returns the value of the response header name, which is case-insensitive.
+func (r batchResponse) header(name string) string {
+	for key, value := range r.Headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// decode decodes the body of a successful response into v,
+// or returns the error of a failed response as a *RespError.
+func (r batchResponse) decode(v interface{}) error {
+	if r.Status == 0 {
+		return fmt.Errorf("batch response %s missing", r.Id)
+	}
+
+	if r.Status >= 400 {
+		resError, err := parseRespError(r.Body, r.Status, r.header("client-request-id"))
+		if err != nil || resError.Err == nil {
+			return fmt.Errorf("batch request %s failed with status %d", r.Id, r.Status)
+		}
+		return resError
+	}
+
+	if v == nil || len(r.Body) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(r.Body, v)
+}
+
+// batch sends requests as a single JSON batch and returns the responses by request id.
+func (c *OneDriveClient) batch(ctx context.Context, requests []batchRequest) (map[string]batchResponse, error) {
+	in := struct {
+		Requests []batchRequest `json:"requests"`
+	}{requests}
+
+	var out struct {
+		Responses []batchResponse `json:"responses"`
+	}
+
+	err := c.doJSON(ctx, http.MethodPost, c.buildURL("/$batch"), in, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make(map[string]batchResponse, len(out.Responses))
+	for _, resp := range out.Responses {
+		responses[resp.Id] = resp
+	}
+
+	return responses, nil
+}
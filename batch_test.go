@@ -0,0 +1,109 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestBatchResponseDecodeError(t *testing.T) {
+	tests := []struct {
+		name          string
+		resp          batchResponse
+		wantStatus    int
+		wantRequestID string
+		wantNotFound  bool
+	}{
+		{
+			name: "request id header",
+			resp: batchResponse{Id: "1", Status: 404,
+				Headers: map[string]string{"Content-Type": "application/json", "Client-Request-Id": "header-id"},
+				Body:    []byte(`{"error":{"code":"itemNotFound","message":"not found","innerError":{"request-id":"inner-id"}}}`)},
+			wantStatus:    404,
+			wantRequestID: "header-id",
+			wantNotFound:  true,
+		},
+		{
+			name: "inner request id",
+			resp: batchResponse{Id: "2", Status: 409,
+				Body: []byte(`{"error":{"code":"nameAlreadyExists","message":"exists","innerError":{"request-id":"inner-id"}}}`)},
+			wantStatus:    409,
+			wantRequestID: "inner-id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.resp.decode(nil)
+
+			var respErr *RespError
+			if !errors.As(err, &respErr) {
+				t.Fatalf("err = %v, want a *RespError", err)
+			}
+			if respErr.StatusCode != tt.wantStatus || respErr.RequestID != tt.wantRequestID {
+				t.Errorf("StatusCode = %d, RequestID = %q, want %d, %q",
+					respErr.StatusCode, respErr.RequestID, tt.wantStatus, tt.wantRequestID)
+			}
+			if IsNotFound(err) != tt.wantNotFound {
+				t.Errorf("IsNotFound = %v, want %v", IsNotFound(err), tt.wantNotFound)
+			}
+		})
+	}
+
+	// a body that is not a Graph error still reports the status
+	err := batchResponse{Id: "3", Status: 502, Body: []byte(`"Bad Gateway"`)}.decode(nil)
+	if err == nil || err.Error() != "batch request 3 failed with status 502" {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestBatchErrorStatus(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"responses":[
+			{"id":"1","status":201,"headers":{"Content-Type":"application/json"},"body":{"id":"new","name":"a"}},
+			{"id":"2","status":429,"headers":{"Retry-After":"10","client-request-id":"throttled-id"},
+			 "body":{"error":{"code":"activityLimitReached","message":"throttled"}}}]}`)
+	}))
+
+	responses, err := c.batch(context.Background(), []batchRequest{
+		{Id: "1", Method: http.MethodPost, URL: "/me/drive/root/children"},
+		{Id: "2", Method: http.MethodPost, URL: "/me/drive/root/children"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var item DriveItem
+	if err := responses["1"].decode(&item); err != nil || item.Id != "new" {
+		t.Errorf("response 1: item %+v, err %v", item, err)
+	}
+
+	err = responses["2"].decode(nil)
+	if code, _ := HTTPStatusCode(err); code != http.StatusTooManyRequests {
+		t.Errorf("response 2: status %d, err %v", code, err)
+	}
+	var respErr *RespError
+	if errors.As(err, &respErr) && respErr.RequestID != "throttled-id" {
+		t.Errorf("response 2: RequestID = %q, want throttled-id", respErr.RequestID)
+	}
+}
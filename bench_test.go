@@ -0,0 +1,245 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// makeItems returns n files with the fields Graph returns for a file.
+func makeItems(n int) []DriveItem {
+	items := make([]DriveItem, n)
+	for i := range items {
+		items[i] = DriveItem{
+			Id:                   fmt.Sprintf("1A2B3C4D5E6F7A8B!%d", 1000+i),
+			Name:                 fmt.Sprintf("file-%05d.txt", i),
+			Size:                 int64(1024 + i),
+			ETag:                 fmt.Sprintf("aMUEyQjNDNEQ1RTZGN0E4QiE%d.1", i),
+			CreatedDateTime:      "2024-05-03T09:14:52.7Z",
+			LastModifiedDateTime: "2024-05-03T09:14:55.44Z",
+			WebURL:               "https://1drv.ms/t/s!AIsqO8TV5vd6gRM",
+			File: &File{
+				MimeType: "text/plain",
+				Hashes:   &Hashes{QuickXorHash: "4LSYpBqT0wjlRE2XHG6bBu2IpBA=", Sha1Hash: "6A0C4F7C1E4B6A19A9E2E0E5773A3B1B3A5E1E9C"},
+			},
+			FileSystemInfo: FileSystemInfo{
+				CreatedDateTime:      "2024-05-03T09:14:52.7Z",
+				LastModifiedDateTime: "2024-05-03T09:14:52Z",
+			},
+			ParentReference: &ParentReference{DriveId: "1a2b3c4d5e6f7a8b", DriveType: "personal",
+				Id: "1A2B3C4D5E6F7A8B!101", Path: "/drive/root:/folder"},
+			CreatedBy: &IdentitySet{User: &Identity{DisplayName: "Test User", Id: "1a2b3c4d5e6f7a8b"}},
+		}
+	}
+
+	return items
+}
+
+// pagedHandler serves items in pages of pageSize linked by @odata.nextLink,
+// with an @odata.deltaLink on the last page. The pages are encoded once.
+func pagedHandler(items []DriveItem, pageSize int) http.Handler {
+	var pages [][]byte
+	for start := 0; start < len(items); start += pageSize {
+		end := start + pageSize
+		if end > len(items) {
+			end = len(items)
+		}
+		value, err := json.Marshal(items[start:end])
+		if err != nil {
+			panic(err)
+		}
+		pages = append(pages, value)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("$skiptoken"))
+		if page < 0 || page >= len(pages) {
+			http.NotFound(w, r)
+			return
+		}
+
+		link := fmt.Sprintf(`"@odata.nextLink":"http://%s%s?$skiptoken=%d"`, r.Host, r.URL.Path, page+1)
+		if page == len(pages)-1 {
+			link = fmt.Sprintf(`"@odata.deltaLink":"http://%s%s?token=latest"`, r.Host, r.URL.Path)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{%s,"value":%s}`, link, pages[page])
+	})
+}
+
+// benchmarkItems reports the number of items listed per second by b.
+func benchmarkItems(b *testing.B, items int) {
+	b.ReportMetric(float64(b.N*items)/b.Elapsed().Seconds(), "items/s")
+}
+
+func BenchmarkListChildrenPaged(b *testing.B) {
+	const pageSize, pages = 200, 5
+
+	c := newTestClient(b, pagedHandler(makeItems(pageSize*pages), pageSize))
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		items, err := c.listAllChildren(ctx, "folder")
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(items) != pageSize*pages {
+			b.Fatalf("%d items, want %d", len(items), pageSize*pages)
+		}
+	}
+	benchmarkItems(b, pageSize*pages)
+}
+
+func BenchmarkDeltaFullEnumeration(b *testing.B) {
+	const pageSize, pages = 200, 50
+
+	c := newTestClient(b, pagedHandler(makeItems(pageSize*pages), pageSize))
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		items, deltaLink, err := c.GetDelta(ctx, "")
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(items) != pageSize*pages || deltaLink == "" {
+			b.Fatalf("%d items and delta link %q, want %d items", len(items), deltaLink, pageSize*pages)
+		}
+	}
+	benchmarkItems(b, pageSize*pages)
+}
+
+// itemResponse is the response of the upload benchmarks for the uploaded file.
+var itemResponse = func() []byte {
+	body, err := json.Marshal(makeItems(1)[0])
+	if err != nil {
+		panic(err)
+	}
+	return body
+}()
+
+func BenchmarkUploadSmallFile(b *testing.B) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 1<<16) // 1 MiB
+
+	c := newTestClient(b, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(itemResponse)
+	}))
+	ctx := context.Background()
+
+	b.SetBytes(int64(len(content)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := c.UploadSmallFile(ctx, "/folder/file.txt", bytes.NewReader(content), "text/plain")
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUploadLargeFile(b *testing.B) {
+	const (
+		size      = 20 << 20
+		chunkSize = 10 * chunkSizeMultiple
+	)
+	content := bytes.Repeat([]byte("0123456789abcdef"), size/16)
+
+	c := newTestClient(b, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if strings.HasSuffix(r.URL.Path, "/createUploadSession") {
+			fmt.Fprintf(w, `{"uploadUrl":"http://%s/upload"}`, r.Host)
+			return
+		}
+
+		// Content-Range: bytes start-end/size
+		io.Copy(io.Discard, r.Body)
+		var start, end, total int64
+		fmt.Sscanf(r.Header.Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &total)
+		if end+1 < total {
+			w.WriteHeader(http.StatusAccepted)
+			fmt.Fprintf(w, `{"nextExpectedRanges":["%d-"]}`, end+1)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write(itemResponse)
+	}))
+	ctx := context.Background()
+	opts := UploadLargeFileOptions{ChunkSize: chunkSize}
+
+	b.SetBytes(size)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := c.UploadLargeFile(ctx, "/folder/large.bin", bytes.NewReader(content), size, opts)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkQuickXorHash(b *testing.B) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 1<<18) // 4 MiB
+	h := newQuickXorHash()
+
+	b.SetBytes(int64(len(content)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.Reset()
+		h.Write(content)
+		h.Sum(nil)
+	}
+}
+
+func BenchmarkDecodeDriveItems(b *testing.B) {
+	body, err := json.Marshal(DriveItems{Value: makeItems(200)})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, strict := range []bool{false, true} {
+		b.Run(fmt.Sprintf("strict=%v", strict), func(b *testing.B) {
+			c := &OneDriveClient{strictJSON: strict}
+
+			b.SetBytes(int64(len(body)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var items DriveItems
+				err := c.unmarshal(body, &items)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
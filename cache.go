@@ -0,0 +1,150 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"container/list"
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cache stores values for a limited time. Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the value for key, or false if there is none or it has expired.
+	Get(key string) (value interface{}, ok bool)
+
+	// Set stores value for key for ttl.
+	Set(key string, value interface{}, ttl time.Duration)
+}
+
+// LRUCache is a Cache that holds a limited number of values,
+// discarding the least recently used value when it is full.
+type LRUCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	order   *list.List // most recently used first
+}
+
+// LRUCache must implement Cache
+var _ Cache = (*LRUCache)(nil)
+
+// lruEntry is a value of an LRUCache.
+type lruEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// NewLRUCache creates an LRUCache that holds up to size values.
+func NewLRUCache(size int) *LRUCache {
+	if size < 1 {
+		size = 1
+	}
+
+	return &LRUCache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Get returns the value for key, or false if there is none or it has expired.
+func (c *LRUCache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*lruEntry)
+	if time.Now().After(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+
+	return entry.value, true
+}
+
+// Set stores value for key for ttl.
+func (c *LRUCache) Set(key string, value interface{}, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := time.Now().Add(ttl)
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = &lruEntry{key, value, expires}
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key, value, expires})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// itemCache caches the responses of DriveItem metadata reads.
+// Every write made by the client starts a new generation, which is part
+// of the cache keys, so values cached before the write are not used.
+type itemCache struct {
+	cache      Cache
+	ttl        time.Duration
+	generation uint64
+}
+
+// key returns the cache key for url in the current generation.
+func (ic *itemCache) key(url string) string {
+	return strconv.FormatUint(atomic.LoadUint64(&ic.generation), 10) + " " + url
+}
+
+// invalidate discards all values cached so far.
+func (ic *itemCache) invalidate() {
+	atomic.AddUint64(&ic.generation, 1)
+}
+
+// getCached is get with the response cached by the cache of the client, if any.
+func (c *OneDriveClient) getCached(ctx context.Context, url string) (body []byte, err error) {
+	if c.cache == nil {
+		return c.get(ctx, url)
+	}
+
+	key := c.cache.key(url)
+	if v, ok := c.cache.cache.Get(key); ok {
+		if body, ok := v.([]byte); ok {
+			return body, nil
+		}
+	}
+
+	body, err = c.get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	c.cache.cache.Set(key, body, c.cache.ttl)
+
+	return body, nil
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without making a request while the circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// circuitBreaker stops requests after threshold consecutive failures.
+// After resetAfter, a single probe request is allowed. If the probe succeeds
+// the breaker closes, otherwise it stays open for another resetAfter.
+type circuitBreaker struct {
+	threshold  int
+	resetAfter time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// allow returns ErrCircuitOpen if a request should not be made.
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		// closed
+		return nil
+	}
+
+	if b.probing || time.Since(b.openedAt) < b.resetAfter {
+		// open, or half-open with a probe in flight
+		return ErrCircuitOpen
+	}
+
+	// half-open, allow one probe
+	b.probing = true
+
+	return nil
+}
+
+// record records the outcome of an allowed request.
+func (b *circuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+
+	if !failed {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = time.Now()
+	}
+}
+
+// recordResult records the outcome of an allowed request made with ctx, which
+// received statusCode or failed with err. A request that failed after its context
+// was done, e.g., cancelled by the caller, says nothing about the availability of
+// Graph, so it only ends a probe without counting as a success or failure.
+func (b *circuitBreaker) recordResult(ctx context.Context, statusCode int, err error) {
+	if err != nil && ctx.Err() != nil {
+		b.mu.Lock()
+		b.probing = false
+		b.mu.Unlock()
+		return
+	}
+
+	b.record(err != nil || isFailure(statusCode))
+}
+
+// isFailure reports whether a response with statusCode indicates
+// that Graph is unavailable rather than that the request was invalid.
+func isFailure(statusCode int) bool {
+	return statusCode == 429 || statusCode >= 500
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	var calls atomic.Int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(int(status.Load()))
+		w.Write([]byte(`{"id":"1"}`))
+	}), WithCircuitBreaker(2, 50*time.Millisecond))
+
+	get := func() error {
+		_, err := c.GetItemByID(context.Background(), "1")
+		return err
+	}
+
+	// two failures open the breaker
+	for i := 0; i < 2; i++ {
+		if err := get(); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("call %d: err = %v, want a 503 error", i, err)
+		}
+	}
+	if err := get(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("server received %d calls, want 2 while open", n)
+	}
+
+	// after resetAfter, a successful probe closes the breaker
+	time.Sleep(60 * time.Millisecond)
+	status.Store(http.StatusOK)
+	if err := get(); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if err := get(); err != nil {
+		t.Fatalf("after probe: %v", err)
+	}
+}
+
+func TestCircuitBreakerCancelled(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// never responds before the caller gives up
+		<-r.Context().Done()
+	}), WithCircuitBreaker(2, time.Hour))
+
+	tests := []struct {
+		name string
+		ctx  func() (context.Context, context.CancelFunc)
+	}{
+		{"cancelled", func() (context.Context, context.CancelFunc) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(20*time.Millisecond, cancel)
+			return ctx, cancel
+		}},
+		{"deadline exceeded", func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(context.Background(), 20*time.Millisecond)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// more calls than the threshold must not open the breaker
+			for i := 0; i < 3; i++ {
+				ctx, cancel := tt.ctx()
+				// GET requests may be shared, so they are not cancelled with their callers
+				err := c.DeleteItem(ctx, "1")
+				cancel()
+				if errors.Is(err, ErrCircuitOpen) {
+					t.Fatalf("call %d: breaker opened by requests cancelled by the caller", i)
+				}
+				if err == nil {
+					t.Fatalf("call %d: want an error", i)
+				}
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerCancelledProbe(t *testing.T) {
+	b := &circuitBreaker{threshold: 1, resetAfter: time.Millisecond}
+	b.record(true)
+	time.Sleep(2 * time.Millisecond)
+
+	if err := b.allow(); err != nil {
+		t.Fatalf("probe not allowed: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b.recordResult(ctx, 0, context.Canceled)
+
+	// the cancelled probe must not keep the breaker half-open forever
+	if err := b.allow(); err != nil {
+		t.Errorf("second probe not allowed after a cancelled probe: %v", err)
+	}
+}
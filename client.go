@@ -0,0 +1,117 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Client lists the methods of OneDriveClient, so code using the client can be
+// tested with a mock, such as onedrivetest.MockClient.
+// ForDrive is not included, since it returns a *OneDriveClient.
+type Client interface {
+	Get(url string) (body []byte, err error)
+	GetMyDrive() (drive Drive, err error)
+	GetSystemDriveInfo(ctx context.Context) (info DriveInfo, err error)
+	GetSpecialFolder(ctx context.Context, name SpecialFolderName) (driveItem DriveItem, err error)
+	ListSpecialFolders(ctx context.Context) ([]SpecialFolder, error)
+	ListMyDrives() (drives Drives, err error)
+	GetMyProfile(ctx context.Context) (user User, err error)
+	ListRecentFiles() (driveItems DriveItems, err error)
+	ListAllRecentFiles(ctx context.Context) ([]DriveItem, error)
+
+	GetItemByID(ctx context.Context, itemID string) (driveItem DriveItem, err error)
+	GetItemByPath(ctx context.Context, path string) (driveItem DriveItem, err error)
+	ListChildren(ctx context.Context, itemID string) (driveItems DriveItems, err error)
+	GetDriveRoot(ctx context.Context) (driveItem DriveItem, err error)
+	GetDriveRootChildren(ctx context.Context) (driveItems DriveItems, err error)
+
+	GraphSearch(ctx context.Context, query string, entityTypes []string, opts GraphSearchOptions) (SearchResponse, error)
+
+	GetDeltaPage(ctx context.Context, link string) (deltaItems DeltaItems, err error)
+	GetDelta(ctx context.Context, deltaLink string) (items []DriveItem, newDeltaLink string, err error)
+	ListAllDriveItems(ctx context.Context, driveID string) ([]DriveItem, error)
+	ListAllDriveItemsChan(ctx context.Context, driveID string) (<-chan DriveItem, <-chan error)
+	GetDriveActivities(ctx context.Context, opts ActivityOptions) (activities []ItemActivity, err error)
+
+	CreateFolder(ctx context.Context, parentID, name string) (driveItem DriveItem, err error)
+	CreateFolderByPath(ctx context.Context, path string) (DriveItem, error)
+	GetItemsModifiedSince(ctx context.Context, folderID string, since time.Time) (items []DriveItem, err error)
+	GetFolderSize(ctx context.Context, folderID string) (size int64, err error)
+	CopyItem(ctx context.Context, itemID, dstParentID, newName string) (DriveItem, error)
+	CrossDriveCopyItem(ctx context.Context, srcDriveID, srcItemID, dstDriveID, dstParentID, newName string) (DriveItem, error)
+	MoveItem(ctx context.Context, itemID, dstParentID, newName string) (driveItem DriveItem, err error)
+	CrossDriveMoveItem(ctx context.Context, srcDriveID, srcItemID, dstDriveID, dstParentID, newName string) (driveItem DriveItem, err error)
+	RotateImage(ctx context.Context, itemID string, degrees int) (driveItem DriveItem, err error)
+	MergeFolder(ctx context.Context, srcFolderID, dstFolderID string, conflictPolicy ConflictBehavior, deleteSource bool) (report MergeReport, err error)
+	DeleteItem(ctx context.Context, itemID string) error
+	PermanentDelete(ctx context.Context, itemID string) error
+	RestoreFromRecycleBin(ctx context.Context, itemID string) (driveItem DriveItem, err error)
+
+	UploadFile(ctx context.Context, path string, r io.ReadSeeker, size int64, opts UploadOptions) (driveItem DriveItem, err error)
+	GetItemDescription(ctx context.Context, itemID string) (string, error)
+	SetItemDescription(ctx context.Context, itemID, description string) error
+	SetFileSystemInfo(ctx context.Context, itemID string, created, modified time.Time) (driveItem DriveItem, err error)
+	UploadSmallFile(ctx context.Context, path string, r io.Reader, contentType string) (driveItem DriveItem, err error)
+	CreateUploadSession(ctx context.Context, path string) (session UploadSession, err error)
+	UploadLargeFile(ctx context.Context, path string, r io.Reader, size int64, opts UploadLargeFileOptions) (driveItem DriveItem, err error)
+	UpdateItemContent(ctx context.Context, itemID string, r io.ReadSeeker, size int64) (driveItem DriveItem, err error)
+	DownloadFile(ctx context.Context, itemID string, w io.Writer) error
+	DownloadAsZip(ctx context.Context, itemIDs []string, w io.Writer) error
+	DownloadToPath(ctx context.Context, itemID string, localPath string, opts DownloadOptions) (err error)
+	ConvertToFormat(ctx context.Context, itemID string, format ConvertFormat, w io.Writer) error
+	VerifyFileIntegrity(ctx context.Context, itemID string, localPath string) (bool, error)
+
+	ListComments(ctx context.Context, itemID string) (comments []Comment, err error)
+	AddComment(ctx context.Context, itemID, text string) (comment Comment, err error)
+	DeleteComment(ctx context.Context, itemID, commentID string) error
+
+	CreateSharingLink(ctx context.Context, itemID string, opts CreateSharingLinkOptions) (permission Permission, err error)
+	GetItemSharingURL(ctx context.Context, item DriveItem, linkType SharingLinkType) (string, error)
+	ResolveRemoteItem(ctx context.Context, item DriveItem) (DriveItem, error)
+	GetSharePointListItem(ctx context.Context, siteID, listID, listItemID string) (listItem ListItem, err error)
+	UpdateSharePointListItem(ctx context.Context, siteID, listID, listItemID string, fields map[string]interface{}) (listItem ListItem, err error)
+	GetItemBySharepointID(ctx context.Context, siteID, listID, listItemID string) (driveItem DriveItem, err error)
+	SetSharingLinkPassword(ctx context.Context, itemID, permissionID, password string) (permission Permission, err error)
+	RemoveSharingLinkPassword(ctx context.Context, itemID, permissionID string) (permission Permission, err error)
+	GetSharedLink(ctx context.Context, sharingURL string) (driveItem DriveItem, err error)
+	GetItemByWebURL(ctx context.Context, webURL string) (driveItem DriveItem, err error)
+	ListPermissions(ctx context.Context, itemID string) (permissions []Permission, err error)
+	GetPermission(ctx context.Context, itemID, permissionID string) (permission Permission, err error)
+	UpdatePermission(ctx context.Context, itemID, permissionID string, roles []string) (permission Permission, err error)
+	RevokeGrants(ctx context.Context, itemID, permissionID string, grantees []DriveRecipient) (permission Permission, err error)
+
+	GetItemPreview(ctx context.Context, itemID string, zoom float64, page *int, allowEdit bool) (info PreviewInfo, err error)
+
+	PollOperation(ctx context.Context, monitorURL string) (driveItem DriveItem, err error)
+
+	GetPublicationInfo(ctx context.Context, itemID string) (PublicationFacet, error)
+	Publish(ctx context.Context, itemID string) error
+
+	CreateSubscription(ctx context.Context, sub Subscription) (subscription Subscription, err error)
+	GetSubscription(ctx context.Context, subscriptionID string) (subscription Subscription, err error)
+	RenewSubscription(ctx context.Context, subscriptionID string, newExpiry time.Time) (subscription Subscription, err error)
+	TokenExpiry() time.Time
+	IsTokenValid(ctx context.Context) (bool, error)
+	GetAccessToken(ctx context.Context) (string, error)
+}
+
+// OneDriveClient must implement Client
+var _ Client = (*OneDriveClient)(nil)
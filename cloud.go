@@ -0,0 +1,82 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import "golang.org/x/oauth2"
+
+// NationalCloud is a deployment of Microsoft Graph with its own Graph and login endpoints.
+type NationalCloud int
+
+const (
+	// CloudGlobal is the global Microsoft Graph service.
+	CloudGlobal NationalCloud = iota
+
+	// CloudUSGovL4 is Microsoft Graph for US Government L4 (GCC High).
+	CloudUSGovL4
+
+	// CloudUSGovL5 is Microsoft Graph for US Government L5 (DOD).
+	CloudUSGovL5
+
+	// CloudChina is Microsoft Graph China operated by 21Vianet.
+	CloudChina
+)
+
+// cloudEndpoint holds the scheme and host of the Graph and login endpoints of a cloud.
+type cloudEndpoint struct {
+	graph string
+	login string
+}
+
+// cloudEndpoints are the endpoints of the national clouds.
+// https://learn.microsoft.com/en-us/graph/deployments
+var cloudEndpoints = map[NationalCloud]cloudEndpoint{
+	CloudGlobal:  {graph: defaultGraphHost, login: "https://login.microsoftonline.com"},
+	CloudUSGovL4: {graph: "https://graph.microsoft.us", login: "https://login.microsoftonline.us"},
+	CloudUSGovL5: {graph: "https://dod-graph.microsoft.us", login: "https://login.microsoftonline.us"},
+	CloudChina:   {graph: "https://microsoftgraph.chinacloudapi.cn", login: "https://login.chinacloudapi.cn"},
+}
+
+// endpoint returns the endpoints of cloud, or of CloudGlobal for an unknown cloud.
+func (cloud NationalCloud) endpoint() cloudEndpoint {
+	e, ok := cloudEndpoints[cloud]
+	if !ok {
+		return cloudEndpoints[CloudGlobal]
+	}
+
+	return e
+}
+
+// authEndpoint returns the Microsoft identity platform endpoint for tenantID.
+func (e cloudEndpoint) authEndpoint(tenantID string) oauth2.Endpoint {
+	base := e.login + "/" + tenantID + "/oauth2/v2.0"
+
+	return oauth2.Endpoint{
+		AuthURL:  base + "/authorize",
+		TokenURL: base + "/token",
+	}
+}
+
+// redirectURL returns the redirect URL for native clients.
+func (e cloudEndpoint) redirectURL() string {
+	return e.login + "/common/oauth2/nativeclient"
+}
+
+// scope returns the scope for app-only access to all permissions granted to the app.
+func (e cloudEndpoint) scope() string {
+	return e.graph + "/.default"
+}
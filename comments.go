@@ -0,0 +1,88 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Comment is a comment on an item.
+type Comment struct {
+	// The unique identifier of the comment. Read-only.
+	Id string `json:"id,omitempty"`
+
+	// The text of the comment.
+	Content string `json:"content,omitempty"`
+
+	// Date and time the comment was created. Read-only.
+	CreatedDateTime string `json:"createdDateTime,omitempty"`
+
+	// Identity of who wrote the comment. Read-only.
+	Author *IdentitySet `json:"author,omitempty"`
+
+	// The replies to the comment. Read-only.
+	Replies []Comment `json:"replies,omitempty"`
+}
+
+// ListComments retrieves the comments on the item identified by itemID.
+// Comments are only supported by OneDrive for Business.
+func (c *OneDriveClient) ListComments(ctx context.Context, itemID string) (comments []Comment, err error) {
+	link := c.driveURL("/items/" + url.PathEscape(itemID) + "/comments")
+	for link != "" {
+		body, err := c.get(ctx, link)
+		if err != nil {
+			return nil, err
+		}
+
+		var page struct {
+			Value    []Comment `json:"value"`
+			NextLink string    `json:"@odata.nextLink,omitempty"`
+		}
+		err = c.unmarshal(body, &page)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, page.Value...)
+		link = page.NextLink
+	}
+
+	return comments, nil
+}
+
+// AddComment adds a comment with text to the item identified by itemID and returns it.
+// Comments are only supported by OneDrive for Business.
+func (c *OneDriveClient) AddComment(ctx context.Context, itemID, text string) (comment Comment, err error) {
+	in := struct {
+		Content string `json:"content"`
+	}{text}
+
+	err = c.doJSON(ctx, http.MethodPost,
+		c.driveURL("/items/"+url.PathEscape(itemID)+"/comments"), in, &comment)
+
+	return comment, err
+}
+
+// DeleteComment deletes the comment identified by commentID from the item identified by itemID.
+func (c *OneDriveClient) DeleteComment(ctx context.Context, itemID, commentID string) error {
+	_, err := c.do(ctx, http.MethodDelete,
+		c.driveURL("/items/"+url.PathEscape(itemID)+"/comments/"+url.PathEscape(commentID)), nil)
+
+	return err
+}
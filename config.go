@@ -0,0 +1,299 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	"golang.org/x/sync/singleflight"
+)
+
+// Config describes how a OneDriveClient authenticates with Microsoft Graph.
+//
+// If ClientSecret is set, the client uses app-only authentication with the
+// client credentials flow. Otherwise the client uses delegated authentication
+// with the token from TokenFile, requesting a new token interactively if needed.
+//
+// NewFromEnvironment reads the Config from the following environment variables:
+//
+//	ONEDRIVE_CLIENT_ID      ClientID
+//	ONEDRIVE_TENANT_ID      TenantID
+//	ONEDRIVE_CLIENT_SECRET  ClientSecret
+//	ONEDRIVE_TOKEN_FILE     TokenFile
+type Config struct {
+	// Application (client) ID registered with Azure AD.
+	// Defaults to the application ID of this package.
+	ClientID string
+
+	// Azure AD tenant ID. Defaults to common, which is not valid for app-only authentication.
+	TenantID string
+
+	// Client secret for app-only authentication. Optional.
+	ClientSecret string
+
+	// File to read the token from and save the token to for delegated authentication.
+	TokenFile string
+
+	// Permissions requested for a new token for delegated authentication. Defaults to
+	// Files.ReadWrite.All and offline_access, which is needed to refresh the token.
+	// Use Files.Read.All instead of Files.ReadWrite.All for read-only access.
+	// A token in TokenFile keeps the scopes it was requested with, so remove
+	// TokenFile to request a token with different scopes.
+	Scopes []string
+
+	// Optional. Called with the new token whenever the token is refreshed,
+	// so applications can persist it across restarts.
+	// The initial token of the client is not reported.
+	// Must be safe to call from multiple goroutines.
+	OnTokenRefreshed func(newToken *oauth2.Token)
+
+	// Maximum time to wait for the advisory lock on TokenFile, which keeps
+	// processes sharing TokenFile from refreshing the token at the same time.
+	// Defaults to 30 seconds. Locking is only supported on Unix-like systems;
+	// elsewhere, such as Windows, TokenFile is not locked.
+	TokenFileLockTimeout time.Duration
+
+	// Maximum time to wait for the token endpoint to exchange the authorization
+	// code of a new token for delegated authentication. Defaults to 30 seconds.
+	TokenExchangeTimeout time.Duration
+
+	// Optional. Called before every request is sent, after the headers of the client
+	// are set, e.g., to add headers. An error aborts the request with that error.
+	// Runs before the hooks added with WithBeforeRequest.
+	BeforeRequest func(req *http.Request) error
+
+	// Optional. Called after every request with the response, or the error if no
+	// response was received, e.g., to record latency. The response body must not be read.
+	// Runs before the hooks added with WithAfterResponse.
+	AfterResponse func(req *http.Request, resp *http.Response, err error)
+
+	// If true, a response with a field that the type it is decoded into does not have
+	// fails with an error that matches ErrUnknownField, to detect changes to the API early,
+	// such as in tests. By default, unknown fields are ignored, as Graph adds new fields
+	// without notice, especially in beta. Instance annotations, whose names start with @,
+	// such as @odata.context or @microsoft.graph.downloadUrl, are always ignored, and
+	// responses of batches and searches are not checked.
+	StrictJSONParsing bool
+}
+
+// defaultScopes are the permissions requested by default for delegated authentication.
+var defaultScopes = []string{"Files.ReadWrite.All", "offline_access"}
+
+// NewWithConfig creates an initialized OneDriveClient using cfg and opts.
+func NewWithConfig(cfg Config, opts ...Option) (*OneDriveClient, error) {
+	o := newOptions(opts)
+
+	// the oauth2 transport wraps the transport of the client in the context
+	base := &http.Client{Transport: o.roundTripper()}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, base)
+
+	if cfg.ClientID == "" {
+		cfg.ClientID = defaultClientID
+	}
+	if cfg.TenantID == "" {
+		cfg.TenantID = defaultTenantID
+	}
+
+	// authenticate with and send requests to the same cloud
+	cloud := o.cloud.endpoint()
+
+	var (
+		ts    oauth2.TokenSource
+		token *oauth2.Token
+	)
+
+	if cfg.ClientSecret != "" {
+		if cfg.TenantID == defaultTenantID {
+			return nil, errors.New("TenantID is required for app-only authentication")
+		}
+
+		conf := &clientcredentials.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			TokenURL:     cloud.authEndpoint(cfg.TenantID).TokenURL,
+			Scopes:       []string{cloud.scope()},
+		}
+
+		// request tokens as needed
+		ts = conf.TokenSource(ctx)
+	} else {
+		if cfg.TokenFile == "" {
+			return nil, errors.New("TokenFile or ClientSecret is required")
+		}
+
+		scopes := cfg.Scopes
+		if len(scopes) == 0 {
+			scopes = defaultScopes
+		}
+
+		conf := &oauth2.Config{
+			ClientID:    cfg.ClientID,
+			Scopes:      scopes,
+			Endpoint:    cloud.authEndpoint(cfg.TenantID),
+			RedirectURL: cloud.redirectURL(),
+		}
+
+		lockTimeout := cfg.TokenFileLockTimeout
+		if lockTimeout == 0 {
+			lockTimeout = defaultLockTimeout
+		}
+
+		exchangeTimeout := cfg.TokenExchangeTimeout
+		if exchangeTimeout == 0 {
+			exchangeTimeout = defaultExchangeTimeout
+		}
+
+		var err error
+		token, err = initialFileToken(ctx, conf, cfg.TokenFile, lockTimeout, exchangeTimeout)
+		if err != nil {
+			return nil, err
+		}
+
+		// refresh the provided token as needed, sharing it through the file
+		ts = oauth2.ReuseTokenSource(token, &fileTokenSource{
+			ctx:         ctx,
+			conf:        conf,
+			fileName:    cfg.TokenFile,
+			lockTimeout: lockTimeout,
+			token:       token,
+		})
+	}
+
+	return newClient(ctx, base, cfg, o, ts, token), nil
+}
+
+// NewFromOAuthConfig creates an initialized OneDriveClient that authorizes requests with token,
+// refreshing it as needed with config, for applications that own the authentication flow,
+// such as with MSAL or an enterprise SSO system. It does not read or write any files or
+// interact with the user. The scopes of config must include the Graph permissions the client
+// uses, e.g., Files.ReadWrite.All, and offline_access for the token to be refreshable.
+// Token refreshes use ctx, so ctx must remain valid while the client is used.
+// All opts apply, except WithAnchorMailbox, which only applies to app-only authentication.
+func NewFromOAuthConfig(ctx context.Context, config *oauth2.Config, token *oauth2.Token, opts ...Option) *OneDriveClient {
+	o := newOptions(opts)
+
+	// the oauth2 transport wraps the transport of the client in the context
+	base := &http.Client{Transport: o.roundTripper()}
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, base)
+
+	return newClient(ctx, base, Config{}, o, config.TokenSource(ctx, token), token)
+}
+
+// newClient creates a OneDriveClient that authorizes requests with tokens from ts,
+// starting with token if known, configured by cfg and o. The base client is the
+// HTTP client in ctx, which carries requests to pre-authenticated URLs without a token.
+func newClient(ctx context.Context, base *http.Client, cfg Config, o *options, ts oauth2.TokenSource, token *oauth2.Token) *OneDriveClient {
+	graphHost := o.graphHost
+	if graphHost == "" {
+		graphHost = o.cloud.endpoint().graph
+		if o.cloud == CloudGlobal {
+			graphHost = GraphBaseURL
+		}
+	}
+
+	client := &OneDriveClient{
+		tokenSource: &tokenRecorder{
+			src:       ts,
+			onRefresh: cfg.OnTokenRefreshed,
+			token:     token,
+		},
+		metrics:   o.metrics,
+		requestID: o.requestID,
+		breaker:   o.breaker,
+
+		downloadConcurrency: o.downloadConcurrency,
+		verifyOnDownload:    o.verifyOnDownload,
+
+		graphHost:    graphHost,
+		graphVersion: o.graphVersion,
+
+		inflight: &singleflight.Group{},
+		cache:    o.cache,
+
+		pollInterval:    o.pollInterval,
+		maxPollInterval: o.maxPollInterval,
+
+		detectContentType: o.detectContentType,
+
+		strictJSON: cfg.StrictJSONParsing,
+	}
+
+	if cfg.BeforeRequest != nil {
+		client.beforeRequest = append(client.beforeRequest, cfg.BeforeRequest)
+	}
+	client.beforeRequest = append(client.beforeRequest, o.beforeRequest...)
+	if cfg.AfterResponse != nil {
+		client.afterResponse = append(client.afterResponse, cfg.AfterResponse)
+	}
+	client.afterResponse = append(client.afterResponse, o.afterResponse...)
+
+	if cfg.ClientSecret != "" {
+		client.anchorMailbox = o.anchorMailbox
+	}
+
+	// create HTTP client that authorizes requests with tokens from the source
+	client.httpClient = oauth2.NewClient(ctx, client.tokenSource)
+	client.httpClient.Timeout = o.timeout
+
+	// transfers of content may take longer than the timeout, which then only applies
+	// to the response headers, see WithTimeout
+	client.streamClient = &http.Client{Transport: client.httpClient.Transport}
+
+	// pre-authenticated URLs must not be sent the token, so use the base transport
+	client.uploadClient = &http.Client{Transport: base.Transport}
+
+	return client
+}
+
+// NewFromEnvironment creates an initialized OneDriveClient using a Config
+// read from environment variables. See Config for the variable names.
+// ONEDRIVE_CLIENT_ID, ONEDRIVE_TENANT_ID, and one of ONEDRIVE_CLIENT_SECRET
+// or ONEDRIVE_TOKEN_FILE are required.
+func NewFromEnvironment(opts ...Option) (*OneDriveClient, error) {
+	cfg := Config{
+		ClientID:     os.Getenv("ONEDRIVE_CLIENT_ID"),
+		TenantID:     os.Getenv("ONEDRIVE_TENANT_ID"),
+		ClientSecret: os.Getenv("ONEDRIVE_CLIENT_SECRET"),
+		TokenFile:    os.Getenv("ONEDRIVE_TOKEN_FILE"),
+	}
+
+	var missing []string
+	if cfg.ClientID == "" {
+		missing = append(missing, "ONEDRIVE_CLIENT_ID")
+	}
+	if cfg.TenantID == "" {
+		missing = append(missing, "ONEDRIVE_TENANT_ID")
+	}
+	if cfg.ClientSecret == "" && cfg.TokenFile == "" {
+		missing = append(missing, "ONEDRIVE_CLIENT_SECRET or ONEDRIVE_TOKEN_FILE")
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing environment variables: %s",
+			strings.Join(missing, ", "))
+	}
+
+	return NewWithConfig(cfg, opts...)
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"strconv"
+	"time"
+)
+
+// ConflictDecision is how a sync resolves a file changed both locally and in the drive.
+type ConflictDecision int
+
+const (
+	// ConflictSkip leaves both files unchanged.
+	ConflictSkip ConflictDecision = iota
+
+	// ConflictUseLocal replaces the file in the drive with the local file.
+	ConflictUseLocal
+
+	// ConflictUseRemote replaces the local file with the file in the drive.
+	ConflictUseRemote
+
+	// ConflictKeepBoth keeps both files by giving one of them a unique name.
+	ConflictKeepBoth
+)
+
+// String returns the name of the decision.
+func (d ConflictDecision) String() string {
+	switch d {
+	case ConflictSkip:
+		return "ConflictSkip"
+	case ConflictUseLocal:
+		return "ConflictUseLocal"
+	case ConflictUseRemote:
+		return "ConflictUseRemote"
+	case ConflictKeepBoth:
+		return "ConflictKeepBoth"
+	}
+
+	return "ConflictDecision(" + strconv.Itoa(int(d)) + ")"
+}
+
+// LocalFileInfo describes the local file of a conflict.
+type LocalFileInfo struct {
+	// The path of the local file.
+	Path string
+
+	// The size of the local file in bytes.
+	Size int64
+
+	// The modification time of the local file.
+	ModTime time.Time
+
+	// The QuickXorHash of the content of the local file, if known.
+	QuickXorHash string
+}
+
+// FileConflictResolver decides how a sync resolves a conflict between the local file
+// and the remote file in the drive, both of which changed since the last sync.
+// An error aborts the sync of the file, e.g., when the user cancels a prompt.
+type FileConflictResolver interface {
+	Resolve(local LocalFileInfo, remote DriveItem) (ConflictDecision, error)
+}
+
+// ConflictResolverFunc adapts a function to a FileConflictResolver.
+type ConflictResolverFunc func(local LocalFileInfo, remote DriveItem) (ConflictDecision, error)
+
+// Resolve returns f(local, remote).
+func (f ConflictResolverFunc) Resolve(local LocalFileInfo, remote DriveItem) (ConflictDecision, error) {
+	return f(local, remote)
+}
+
+// Built-in resolvers.
+var (
+	// NewerWinsResolver uses the file modified last, comparing the modification time of
+	// the local file with the FileSystemInfo of the remote file, which is the time set by
+	// the client that uploaded it, or else with the time recorded by Graph. If the times
+	// are equal it skips the file, and if the remote time is unknown it keeps both.
+	NewerWinsResolver FileConflictResolver = ConflictResolverFunc(newerWins)
+
+	// RemoteWinsResolver always uses the remote file.
+	RemoteWinsResolver FileConflictResolver = ConflictResolverFunc(
+		func(LocalFileInfo, DriveItem) (ConflictDecision, error) { return ConflictUseRemote, nil })
+
+	// LocalWinsResolver always uses the local file.
+	LocalWinsResolver FileConflictResolver = ConflictResolverFunc(
+		func(LocalFileInfo, DriveItem) (ConflictDecision, error) { return ConflictUseLocal, nil })
+
+	// SkipResolver always skips the file.
+	SkipResolver FileConflictResolver = ConflictResolverFunc(
+		func(LocalFileInfo, DriveItem) (ConflictDecision, error) { return ConflictSkip, nil })
+)
+
+// newerWins implements NewerWinsResolver.
+func newerWins(local LocalFileInfo, remote DriveItem) (ConflictDecision, error) {
+	remoteTime, err := remote.modifiedTime()
+	if err != nil {
+		return ConflictKeepBoth, nil
+	}
+
+	// OneDrive keeps whole seconds
+	localTime := local.ModTime.Truncate(time.Second)
+	remoteTime = remoteTime.Truncate(time.Second)
+
+	switch {
+	case localTime.After(remoteTime):
+		return ConflictUseLocal, nil
+	case remoteTime.After(localTime):
+		return ConflictUseRemote, nil
+	}
+
+	return ConflictSkip, nil
+}
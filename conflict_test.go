@@ -0,0 +1,84 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConflictResolvers(t *testing.T) {
+	modified := time.Date(2019, 3, 14, 15, 9, 26, 0, time.UTC)
+	remote := func(fileSystemTime, itemTime string) DriveItem {
+		item := DriveItem{LastModifiedDateTime: itemTime}
+		item.FileSystemInfo.LastModifiedDateTime = fileSystemTime
+		return item
+	}
+
+	tests := []struct {
+		name     string
+		resolver FileConflictResolver
+		local    time.Time
+		remote   DriveItem
+		want     ConflictDecision
+	}{
+		{"newer local", NewerWinsResolver, modified.Add(time.Second), remote("2019-03-14T15:09:26Z", ""), ConflictUseLocal},
+		{"newer remote", NewerWinsResolver, modified.Add(-time.Second), remote("2019-03-14T15:09:26Z", ""), ConflictUseRemote},
+		{"equal", NewerWinsResolver, modified, remote("2019-03-14T15:09:26Z", ""), ConflictSkip},
+		{"equal in other zone", NewerWinsResolver, modified, remote("2019-03-14T16:09:26+01:00", ""), ConflictSkip},
+		{"fraction of local second", NewerWinsResolver, modified.Add(999 * time.Millisecond), remote("2019-03-14T15:09:26Z", ""), ConflictSkip},
+		{"fraction of remote second", NewerWinsResolver, modified, remote("2019-03-14T15:09:26.75Z", ""), ConflictSkip},
+		{"file system time before item time", NewerWinsResolver, modified, remote("2019-03-14T15:09:25Z", "2019-03-14T15:09:30Z"), ConflictUseLocal},
+		{"fallback to item time", NewerWinsResolver, modified, remote("", "2019-03-14T15:09:30Z"), ConflictUseRemote},
+		{"unparsable time", NewerWinsResolver, modified, remote("yesterday", "2019-03-14T15:09:26Z"), ConflictKeepBoth},
+		{"unknown time", NewerWinsResolver, modified, remote("", ""), ConflictKeepBoth},
+		{"remote wins", RemoteWinsResolver, modified.Add(time.Hour), remote("2019-03-14T15:09:26Z", ""), ConflictUseRemote},
+		{"local wins", LocalWinsResolver, modified.Add(-time.Hour), remote("2019-03-14T15:09:26Z", ""), ConflictUseLocal},
+		{"skip", SkipResolver, modified.Add(time.Hour), remote("2019-03-14T15:09:26Z", ""), ConflictSkip},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.resolver.Resolve(LocalFileInfo{Path: "a.txt", ModTime: tt.local}, tt.remote)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestConflictDecisionString(t *testing.T) {
+	tests := []struct {
+		decision ConflictDecision
+		want     string
+	}{
+		{ConflictSkip, "ConflictSkip"},
+		{ConflictUseLocal, "ConflictUseLocal"},
+		{ConflictUseRemote, "ConflictUseRemote"},
+		{ConflictKeepBoth, "ConflictKeepBoth"},
+		{ConflictDecision(7), "ConflictDecision(7)"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.decision.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
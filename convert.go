@@ -0,0 +1,106 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// ErrUnsupportedConversion is returned when an item cannot be converted to the requested format.
+var ErrUnsupportedConversion = errors.New("unsupported conversion")
+
+// ConvertFormat is a format that ConvertToFormat can convert items to.
+type ConvertFormat string
+
+const (
+	// Converts Office documents to PDF.
+	FormatPDF ConvertFormat = "pdf"
+
+	// Converts Office documents to HTML.
+	FormatHTML ConvertFormat = "html"
+
+	// Converts 3D models to GLB.
+	FormatGLB ConvertFormat = "glb"
+)
+
+// officeMimeTypes lists the MIME types of the Office documents that can be converted.
+var officeMimeTypes = map[string]bool{
+	"application/msword": true,
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": true,
+	"application/vnd.ms-excel": true,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         true,
+	"application/vnd.ms-powerpoint":                                             true,
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": true,
+}
+
+// modelExtensions lists the extensions of the 3D models that can be converted to GLB.
+var modelExtensions = map[string]bool{
+	".cool": true, ".fbx": true, ".obj": true, ".ply": true, ".stl": true, ".3mf": true,
+}
+
+// canConvert reports whether item can be converted to format.
+func canConvert(item DriveItem, format ConvertFormat) bool {
+	if item.File == nil {
+		return false
+	}
+
+	switch format {
+	case FormatPDF, FormatHTML:
+		return officeMimeTypes[strings.ToLower(item.MimeType())]
+	case FormatGLB:
+		return modelExtensions[strings.ToLower(path.Ext(item.Name))]
+	}
+
+	return false
+}
+
+// ConvertToFormat writes the content of the item identified by itemID, converted to format, to w.
+// PDF and HTML require an Office document (Word, Excel, or PowerPoint), and GLB requires
+// a 3D model. The item metadata is checked first, and ErrUnsupportedConversion is returned
+// for other items without requesting the conversion.
+func (c *OneDriveClient) ConvertToFormat(ctx context.Context, itemID string, format ConvertFormat, w io.Writer) error {
+	item, err := c.GetItemByID(ctx, itemID)
+	if err != nil {
+		return err
+	}
+
+	if !canConvert(item, format) {
+		return ErrUnsupportedConversion
+	}
+
+	return c.download(ctx,
+		c.driveURL("/items/"+url.PathEscape(itemID)+"/content?format="+url.QueryEscape(string(format))), w)
+}
+
+// download writes the content at url to w.
+func (c *OneDriveClient) download(ctx context.Context, url string, w io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.streamTo(req, w)
+
+	return err
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// itemReference identifies the destination folder of a copy or move.
+type itemReference struct {
+	DriveId string `json:"driveId,omitempty"`
+	Id      string `json:"id,omitempty"`
+}
+
+// copyMove is the request body to copy or move an item.
+type copyMove struct {
+	ParentReference itemReference `json:"parentReference"`
+	Name            string        `json:"name,omitempty"`
+}
+
+// CopyItem copies the item identified by itemID into the folder identified by
+// dstParentID, named newName or with its current name if newName is empty,
+// and returns the copy. Graph copies asynchronously, so CopyItem waits for the
+// copy to complete with PollOperation.
+func (c *OneDriveClient) CopyItem(ctx context.Context, itemID, dstParentID, newName string) (DriveItem, error) {
+	return c.copyItem(ctx, c.driveURL("/items/"+url.PathEscape(itemID)),
+		itemReference{Id: dstParentID}, newName)
+}
+
+// CrossDriveCopyItem is CopyItem for the item identified by srcItemID in the drive
+// identified by srcDriveID into a folder in the drive identified by dstDriveID,
+// e.g., from a OneDrive to a SharePoint document library.
+func (c *OneDriveClient) CrossDriveCopyItem(ctx context.Context, srcDriveID, srcItemID, dstDriveID, dstParentID, newName string) (DriveItem, error) {
+	return c.copyItem(ctx, c.itemInDriveURL(srcDriveID, srcItemID),
+		itemReference{DriveId: dstDriveID, Id: dstParentID}, newName)
+}
+
+// copyItem copies the item at itemURL to dst and waits for the copy to complete.
+func (c *OneDriveClient) copyItem(ctx context.Context, itemURL string, dst itemReference, newName string) (DriveItem, error) {
+	b, err := json.Marshal(copyMove{ParentReference: dst, Name: newName})
+	if err != nil {
+		return DriveItem{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, itemURL+"/copy", bytes.NewReader(b))
+	if err != nil {
+		return DriveItem{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, _, err := c.send(req)
+	if err != nil {
+		return DriveItem{}, err
+	}
+
+	monitorURL := resp.Header.Get("Location")
+	if monitorURL == "" {
+		return DriveItem{}, errors.New("copy did not return a monitor URL")
+	}
+
+	status, err := c.pollOperation(ctx, monitorURL)
+	if err != nil {
+		return DriveItem{}, err
+	}
+
+	// the copy is in the destination drive
+	client := c
+	if dst.DriveId != "" {
+		client = c.ForDrive(dst.DriveId)
+	}
+
+	return client.GetItemByID(ctx, status.ResourceId)
+}
+
+// MoveItem moves the item identified by itemID into the folder identified by
+// dstParentID, renamed to newName unless it is empty, and returns the moved item.
+func (c *OneDriveClient) MoveItem(ctx context.Context, itemID, dstParentID, newName string) (driveItem DriveItem, err error) {
+	in := copyMove{ParentReference: itemReference{Id: dstParentID}, Name: newName}
+
+	err = c.doJSON(ctx, http.MethodPatch, c.driveURL("/items/"+url.PathEscape(itemID)), in, &driveItem)
+
+	return driveItem, err
+}
+
+// CrossDriveMoveItem is MoveItem for the item identified by srcItemID in the drive
+// identified by srcDriveID into a folder in the drive identified by dstDriveID.
+// The request includes the destination drive ID in the parent reference.
+func (c *OneDriveClient) CrossDriveMoveItem(ctx context.Context, srcDriveID, srcItemID, dstDriveID, dstParentID, newName string) (driveItem DriveItem, err error) {
+	in := copyMove{
+		ParentReference: itemReference{DriveId: dstDriveID, Id: dstParentID},
+		Name:            newName,
+	}
+
+	err = c.doJSON(ctx, http.MethodPatch, c.itemInDriveURL(srcDriveID, srcItemID), in, &driveItem)
+
+	return driveItem, err
+}
+
+// itemInDriveURL returns the URL of the item identified by itemID in the drive identified by driveID.
+func (c *OneDriveClient) itemInDriveURL(driveID, itemID string) string {
+	return c.buildURL("/drives/" + url.PathEscape(driveID) + "/items/" + url.PathEscape(itemID))
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// copyMoveServer is a handler for copy and move requests, which records their bodies.
+type copyMoveServer struct {
+	mu     sync.Mutex
+	bodies map[string]map[string]interface{} // by method and path
+	polls  int
+
+	copyStatus  int    // of the copy request, 202 Accepted if zero
+	finalStatus string // of the copy operation, completed if empty
+}
+
+func (s *copyMoveServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Method == http.MethodPost || r.Method == http.MethodPatch {
+		var body map[string]interface{}
+		b, _ := io.ReadAll(r.Body)
+		json.Unmarshal(b, &body)
+		if s.bodies == nil {
+			s.bodies = make(map[string]map[string]interface{})
+		}
+		s.bodies[r.Method+" "+r.URL.Path] = body
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.URL.Path == "/monitor":
+		if r.Header.Get("Authorization") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.polls++
+		status := "inProgress"
+		if s.polls > 1 {
+			status = s.finalStatus
+			if status == "" {
+				status = "completed"
+			}
+		}
+		fmt.Fprintf(w, `{"operation":"itemCopy","status":%q,"resourceId":"copy","statusDescription":"quota exceeded"}`, status)
+	case r.Method == http.MethodPost:
+		status := s.copyStatus
+		if status == 0 {
+			status = http.StatusAccepted
+		}
+		if status == http.StatusAccepted {
+			w.Header().Set("Location", "http://"+r.Host+"/monitor")
+		}
+		w.WriteHeader(status)
+		if status == http.StatusNotFound {
+			fmt.Fprint(w, `{"error":{"code":"itemNotFound","message":"not found"}}`)
+		}
+	case r.Method == http.MethodGet:
+		fmt.Fprintf(w, `{"id":"copy","name":"copied","parentReference":{"id":"dst","path":%q}}`, r.URL.Path)
+	case r.Method == http.MethodPatch && r.URL.Path == "/v1.0/me/drive/items/conflict":
+		w.WriteHeader(http.StatusConflict)
+		fmt.Fprint(w, `{"error":{"code":"nameAlreadyExists","message":"exists"}}`)
+	case r.Method == http.MethodPatch:
+		fmt.Fprint(w, `{"id":"moved","name":"moved","parentReference":{"id":"dst"}}`)
+	}
+}
+
+// body returns the recorded body of the request with method and path.
+func (s *copyMoveServer) body(method, path string) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.bodies[method+" "+path]
+}
+
+func TestCopyItem(t *testing.T) {
+	s := &copyMoveServer{}
+	c := newTestClient(t, s, WithPollInterval(time.Millisecond, time.Millisecond))
+	ctx := context.Background()
+
+	item, err := c.CopyItem(ctx, "src", "dst", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the copy is retrieved from the drive of the client
+	if item.Id != "copy" || item.ParentReference.Path != "/v1.0/me/drive/items/copy" {
+		t.Errorf("item = %+v", item)
+	}
+	body := s.body(http.MethodPost, "/v1.0/me/drive/items/src/copy")
+	if fmt.Sprint(body) != "map[parentReference:map[id:dst]]" {
+		t.Errorf("body = %v, want the destination without a name", body)
+	}
+	if s.polls != 2 {
+		t.Errorf("%d polls, want 2", s.polls)
+	}
+
+	// the copy is retrieved from the destination drive
+	item, err = c.CrossDriveCopyItem(ctx, "srcDrive", "src", "dstDrive", "dst", "renamed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.ParentReference.Path != "/v1.0/drives/dstDrive/items/copy" {
+		t.Errorf("item = %+v", item)
+	}
+	body = s.body(http.MethodPost, "/v1.0/drives/srcDrive/items/src/copy")
+	if fmt.Sprint(body) != "map[name:renamed parentReference:map[driveId:dstDrive id:dst]]" {
+		t.Errorf("body = %v", body)
+	}
+}
+
+func TestCopyItemErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		server *copyMoveServer
+		want   func(err error) bool
+	}{
+		{"not found", &copyMoveServer{copyStatus: http.StatusNotFound}, IsNotFound},
+		{"no monitor", &copyMoveServer{copyStatus: http.StatusOK}, func(err error) bool {
+			return err != nil && err.Error() == "copy did not return a monitor URL"
+		}},
+		{"failed", &copyMoveServer{finalStatus: "failed"}, func(err error) bool {
+			return errors.Is(err, ErrOperationFailed) && err.Error() == "operation failed: quota exceeded"
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, tt.server, WithPollInterval(time.Millisecond, time.Millisecond))
+
+			item, err := c.CopyItem(context.Background(), "src", "dst", "")
+			if !tt.want(err) {
+				t.Errorf("err = %v", err)
+			}
+			if item.Id != "" {
+				t.Errorf("item = %+v, want none", item)
+			}
+		})
+	}
+}
+
+func TestMoveItem(t *testing.T) {
+	s := &copyMoveServer{}
+	c := newTestClient(t, s)
+	ctx := context.Background()
+
+	item, err := c.MoveItem(ctx, "src", "dst", "new")
+	if err != nil || item.Id != "moved" {
+		t.Fatalf("item %+v, err %v", item, err)
+	}
+	body := s.body(http.MethodPatch, "/v1.0/me/drive/items/src")
+	if fmt.Sprint(body) != "map[name:new parentReference:map[id:dst]]" {
+		t.Errorf("body = %v", body)
+	}
+
+	item, err = c.CrossDriveMoveItem(ctx, "srcDrive", "src", "dstDrive", "dst", "")
+	if err != nil || item.Id != "moved" {
+		t.Fatalf("item %+v, err %v", item, err)
+	}
+	body = s.body(http.MethodPatch, "/v1.0/drives/srcDrive/items/src")
+	ref, _ := body["parentReference"].(map[string]interface{})
+	if ref["driveId"] != "dstDrive" || ref["id"] != "dst" {
+		t.Errorf("parentReference = %v, want the destination drive and folder", ref)
+	}
+	if _, ok := body["name"]; ok {
+		t.Errorf("body = %v, want no name", body)
+	}
+
+	_, err = c.MoveItem(ctx, "conflict", "dst", "")
+	if !isNameConflict(err) {
+		t.Errorf("err = %v, want a name conflict", err)
+	}
+	if code, _ := HTTPStatusCode(err); code != http.StatusConflict {
+		t.Errorf("status = %d, want %d", code, http.StatusConflict)
+	}
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// NewCorrelationID returns a random (version 4) UUID for use with WithCorrelationID.
+func NewCorrelationID() string {
+	b := make([]byte, 16)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	// set version 4 and RFC 4122 variant
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// GetCorrelationID returns the request ID of the RespError in the chain of err,
+// or an empty string if there is none.
+func GetCorrelationID(err error) string {
+	var respErr *RespError
+	if errors.As(err, &respErr) {
+		return respErr.RequestID
+	}
+
+	return ""
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// DeleteItem deletes the item identified by itemID by moving it to the recycle bin.
+// This is a soft delete: the item can be restored with RestoreFromRecycleBin
+// until the recycle bin is emptied. Use PermanentDelete to erase it instead.
+func (c *OneDriveClient) DeleteItem(ctx context.Context, itemID string) error {
+	_, err := c.do(ctx, http.MethodDelete, c.driveURL("/items/"+url.PathEscape(itemID)), nil)
+
+	return err
+}
+
+// PermanentDelete permanently deletes the item identified by itemID without moving
+// it to the recycle bin, so it cannot be restored, e.g., to honor erasure requests.
+// Only supported by OneDrive for Business and SharePoint drives.
+func (c *OneDriveClient) PermanentDelete(ctx context.Context, itemID string) error {
+	return c.doJSON(ctx, http.MethodPost,
+		c.driveURL("/items/"+url.PathEscape(itemID)+"/permanentDelete"), nil, nil)
+}
+
+// RestoreFromRecycleBin restores the item identified by itemID, which was deleted
+// with DeleteItem, to its original location and returns it.
+// Items deleted with PermanentDelete cannot be restored.
+// Only supported by OneDrive Personal.
+func (c *OneDriveClient) RestoreFromRecycleBin(ctx context.Context, itemID string) (driveItem DriveItem, err error) {
+	err = c.doJSON(ctx, http.MethodPost,
+		c.driveURL("/items/"+url.PathEscape(itemID)+"/restore"), struct{}{}, &driveItem)
+
+	return driveItem, err
+}
@@ -0,0 +1,415 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// GetDeltaPage retrieves a page of changes in the drive.
+// link is a NextLink or DeltaLink from a previous page,
+// or empty to enumerate the entire drive from scratch.
+func (c *OneDriveClient) GetDeltaPage(ctx context.Context, link string) (deltaItems DeltaItems, err error) {
+	if link == "" {
+		link = c.driveURL("/root/delta")
+	}
+
+	body, err := c.get(ctx, link)
+	if err != nil {
+		return DeltaItems{}, err
+	}
+
+	err = c.unmarshal(body, &deltaItems)
+
+	return deltaItems, err
+}
+
+// GetDelta retrieves all changes in the drive since deltaLink, following every
+// NextLink, and returns the changes and the DeltaLink for the next call.
+// An empty deltaLink enumerates the entire drive.
+func (c *OneDriveClient) GetDelta(ctx context.Context, deltaLink string) (items []DriveItem, newDeltaLink string, err error) {
+	link := deltaLink
+	for {
+		page, err := c.GetDeltaPage(ctx, link)
+		if err != nil {
+			return nil, "", err
+		}
+		items = append(items, page.Value...)
+
+		if page.NextLink == "" {
+			return items, page.DeltaLink, nil
+		}
+		link = page.NextLink
+	}
+}
+
+// ErrMaxItemsExceeded is returned by ListAllItems when the drive has more items than allowed.
+var ErrMaxItemsExceeded = errors.New("maximum number of items exceeded")
+
+// ListAllOptions controls ListAllItems.
+type ListAllOptions struct {
+	// Maximum number of items to return, or zero for unlimited.
+	MaxItems int
+
+	// If not nil, only items for which Filter returns true are returned.
+	Filter func(DriveItem) bool
+}
+
+// ListAllItems returns every item in the drive of client by enumerating the drive
+// from scratch with the delta API. If more than opts.MaxItems items match,
+// ErrMaxItemsExceeded is returned without fetching the remaining pages.
+func ListAllItems(ctx context.Context, client *OneDriveClient, opts ListAllOptions) (items []DriveItem, err error) {
+	link := ""
+	for {
+		page, err := client.GetDeltaPage(ctx, link)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range page.Value {
+			if item.Deleted != nil {
+				continue
+			}
+			if opts.Filter != nil && !opts.Filter(item) {
+				continue
+			}
+			if opts.MaxItems > 0 && len(items) == opts.MaxItems {
+				return nil, ErrMaxItemsExceeded
+			}
+			items = append(items, item)
+		}
+
+		if page.NextLink == "" {
+			return items, nil
+		}
+		link = page.NextLink
+	}
+}
+
+// ListAllDriveItems returns every item in the drive identified by driveID,
+// or in the drive of the client if driveID is "me", using the delta API, which
+// returns all items of the drive in a single paged enumeration instead of listing
+// every folder. A large drive can have tens of thousands of items, so use
+// ListAllDriveItemsChan to process the items without holding all of them in memory.
+func (c *OneDriveClient) ListAllDriveItems(ctx context.Context, driveID string) ([]DriveItem, error) {
+	return ListAllItems(ctx, c.forDriveID(driveID), ListAllOptions{})
+}
+
+// ListAllDriveItemsChan is ListAllDriveItems returning the items on a channel as
+// the pages arrive. The item channel is closed after the last item; then the error
+// channel receives the error that ended the enumeration, if any, and is closed.
+// The item channel is unbuffered, so pages are only fetched as fast as the caller
+// receives the items. The caller must receive all items or cancel ctx.
+func (c *OneDriveClient) ListAllDriveItemsChan(ctx context.Context, driveID string) (<-chan DriveItem, <-chan error) {
+	client := c.forDriveID(driveID)
+	items := make(chan DriveItem)
+	errc := make(chan error, 1)
+
+	go func() {
+		defer close(errc)
+
+		err := func() error {
+			defer close(items)
+
+			link := ""
+			for {
+				page, err := client.GetDeltaPage(ctx, link)
+				if err != nil {
+					return err
+				}
+
+				for _, item := range page.Value {
+					if item.Deleted != nil {
+						continue
+					}
+					select {
+					case items <- item:
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				}
+
+				if page.NextLink == "" {
+					return nil
+				}
+				link = page.NextLink
+			}
+		}()
+		if err != nil {
+			errc <- err
+		}
+	}()
+
+	return items, errc
+}
+
+// forDriveID returns c for driveID "me" or empty, or else c scoped to driveID.
+func (c *OneDriveClient) forDriveID(driveID string) *OneDriveClient {
+	if driveID == "" || driveID == "me" {
+		return c
+	}
+
+	return c.ForDrive(driveID)
+}
+
+// ProcessDeltaOrdered calls handler for items, such as the changes returned by GetDelta,
+// so that a local copy can be updated safely: first the folders, one at a time with a parent
+// before its children, then the files, up to concurrency at a time, and finally the deleted
+// items, one at a time with children before their parent. Processing stops at the first
+// error returned by handler or when ctx is done.
+func ProcessDeltaOrdered(ctx context.Context, items []DriveItem, handler func(DriveItem) error, concurrency int) error {
+	var folders, files, deleted []DriveItem
+	for _, item := range items {
+		switch {
+		case item.Deleted != nil:
+			deleted = append(deleted, item)
+		case item.Folder != nil || item.Root != nil:
+			folders = append(folders, item)
+		default:
+			files = append(files, item)
+		}
+	}
+
+	folders, err := FlattenDriveItems(folders)
+	if err != nil {
+		return err
+	}
+	for _, folder := range folders {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := handler(folder); err != nil {
+			return err
+		}
+	}
+
+	err = processConcurrently(ctx, files, handler, concurrency)
+	if err != nil {
+		return err
+	}
+
+	deleted, err = FlattenDriveItems(deleted)
+	if err != nil {
+		return err
+	}
+	for i := len(deleted) - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := handler(deleted[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// processConcurrently calls handler for items, up to concurrency at a time,
+// and returns the first error.
+func processConcurrently(ctx context.Context, items []DriveItem, handler func(DriveItem) error, concurrency int) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	sem := make(chan struct{}, concurrency)
+
+	for _, item := range items {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(item DriveItem) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := handler(item); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(item)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	return ctx.Err()
+}
+
+// isResyncRequired reports whether err indicates that a delta link has expired
+// and the drive must be enumerated again from scratch.
+func isResyncRequired(err error) bool {
+	var respErr *RespError
+	if !errors.As(err, &respErr) || respErr.Err == nil {
+		return false
+	}
+
+	switch respErr.Err.Code {
+	case "syncStateNotFound", "resyncRequired",
+		"resyncChangesApplyDifferences", "resyncChangesUploadDifferences":
+		return true
+	}
+
+	return false
+}
+
+const (
+	// DefaultDeltaPollInterval is the default interval between delta polls.
+	DefaultDeltaPollInterval = 30 * time.Second
+
+	// MinDeltaPollInterval is the shortest interval between delta polls,
+	// to stay within the Graph throttling guidelines.
+	MinDeltaPollInterval = 10 * time.Second
+)
+
+// DeltaEventType identifies the kind of DeltaEvent.
+type DeltaEventType int
+
+const (
+	// DeltaChangesEvent carries the changes, or the error, from a poll cycle.
+	DeltaChangesEvent DeltaEventType = iota
+
+	// SyncResetEvent reports that the delta link expired. The changes of the
+	// following DeltaChangesEvent are a full enumeration of the drive.
+	SyncResetEvent
+)
+
+// DeltaEvent is the result of a DeltaPoller poll cycle.
+type DeltaEvent struct {
+	Type  DeltaEventType
+	Items []DriveItem
+	Err   error
+}
+
+// DeltaPoller repeatedly polls the delta API for changes in a drive.
+// The first poll cycle enumerates the entire drive.
+type DeltaPoller struct {
+	client   *OneDriveClient
+	interval time.Duration
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewDeltaPoller creates a DeltaPoller for the drive of client that polls every interval.
+// Zero means DefaultDeltaPollInterval; shorter intervals are raised to MinDeltaPollInterval.
+func NewDeltaPoller(client *OneDriveClient, interval time.Duration) *DeltaPoller {
+	if interval == 0 {
+		interval = DefaultDeltaPollInterval
+	}
+	if interval < MinDeltaPollInterval {
+		interval = MinDeltaPollInterval
+	}
+
+	return &DeltaPoller{client: client, interval: interval}
+}
+
+// Start starts polling and returns the channel that receives the poll results.
+// Cycles without changes are not reported. The channel is closed after ctx
+// is done or Stop is called. Start must not be called again before Stop.
+func (p *DeltaPoller) Start(ctx context.Context) <-chan DeltaEvent {
+	ctx, cancel := context.WithCancel(ctx)
+	events := make(chan DeltaEvent)
+	done := make(chan struct{})
+
+	p.mu.Lock()
+	p.cancel = cancel
+	p.done = done
+	p.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		defer close(events)
+
+		send := func(event DeltaEvent) bool {
+			select {
+			case events <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		deltaLink := ""
+		for {
+			items, newDeltaLink, err := p.client.GetDelta(ctx, deltaLink)
+			switch {
+			case err != nil && deltaLink != "" && isResyncRequired(err):
+				// start over with a full enumeration without waiting
+				deltaLink = ""
+				if !send(DeltaEvent{Type: SyncResetEvent}) {
+					return
+				}
+				continue
+			case err != nil:
+				if ctx.Err() != nil {
+					return
+				}
+				if !send(DeltaEvent{Type: DeltaChangesEvent, Err: err}) {
+					return
+				}
+			default:
+				deltaLink = newDeltaLink
+				if len(items) > 0 && !send(DeltaEvent{Type: DeltaChangesEvent, Items: items}) {
+					return
+				}
+			}
+
+			select {
+			case <-time.After(p.interval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events
+}
+
+// Stop stops polling and waits until the event channel is closed.
+func (p *DeltaPoller) Stop() {
+	p.mu.Lock()
+	cancel, done := p.cancel, p.done
+	p.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
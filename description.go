@@ -0,0 +1,60 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"unicode/utf8"
+)
+
+// MaxDescriptionLength is the maximum number of characters in the description of an item.
+const MaxDescriptionLength = 1024
+
+// ErrDescriptionTooLong is returned by SetItemDescription for a description
+// longer than MaxDescriptionLength characters.
+var ErrDescriptionTooLong = errors.New("description too long")
+
+// GetItemDescription retrieves the user-visible description of the item identified by itemID.
+// Graph documents the description for OneDrive Personal; other drives may return none.
+func (c *OneDriveClient) GetItemDescription(ctx context.Context, itemID string) (string, error) {
+	body, err := c.get(ctx, c.driveURL("/items/"+url.PathEscape(itemID)+"?$select=id,description"))
+	if err != nil {
+		return "", err
+	}
+
+	var item DriveItem
+	err = c.unmarshal(body, &item)
+
+	return item.Description, err
+}
+
+// SetItemDescription sets the user-visible description of the item identified by itemID.
+// ErrDescriptionTooLong is returned without a request for a description longer than
+// MaxDescriptionLength characters. An empty description removes it.
+func (c *OneDriveClient) SetItemDescription(ctx context.Context, itemID, description string) error {
+	if utf8.RuneCountInString(description) > MaxDescriptionLength {
+		return ErrDescriptionTooLong
+	}
+
+	in := map[string]string{"description": description}
+
+	return c.doJSON(ctx, http.MethodPatch, c.driveURL("/items/"+url.PathEscape(itemID)), in, nil)
+}
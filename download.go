@@ -0,0 +1,309 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DownloadFile writes the content of the file identified by itemID to w.
+// If the client verifies downloads, see WithVerifyOnDownload, and the content does not
+// have the hash of the file, an IntegrityError is returned after all of the content has
+// been written to w, so the caller must discard the content, e.g., delete the file.
+func (c *OneDriveClient) DownloadFile(ctx context.Context, itemID string, w io.Writer) error {
+	if c.verifyOnDownload {
+		return c.downloadVerified(ctx, itemID, w)
+	}
+
+	return c.download(ctx, c.driveURL("/items/"+url.PathEscape(itemID)+"/content"), w)
+}
+
+// DownloadOptions controls DownloadToPath.
+type DownloadOptions struct {
+	// If true, the modification time of the local file is set to the modification
+	// time of the file in the drive, as recorded by the client that uploaded it,
+	// or else as recorded by Graph.
+	PreserveTimestamps bool
+
+	// If true, an existing local file is replaced. Otherwise DownloadToPath fails with
+	// an error that matches fs.ErrExist if the local file exists.
+	Overwrite bool
+
+	// If true, the content is verified against the hash of the file, even if the client
+	// does not verify downloads, see WithVerifyOnDownload.
+	VerifyIntegrity bool
+}
+
+// DownloadToPath downloads the content of the file identified by itemID to a file at localPath,
+// created with mode 0666 before the umask. With Overwrite, the content is downloaded to a
+// temporary file in the same directory, which replaces localPath only if the download succeeds,
+// so an existing file is kept if it fails. Otherwise, a partially written file is deleted if
+// the download fails, including an IntegrityError.
+func (c *OneDriveClient) DownloadToPath(ctx context.Context, itemID string, localPath string, opts DownloadOptions) (err error) {
+	verify := opts.VerifyIntegrity || c.verifyOnDownload
+
+	// retrieve the modification time and the hash once, so both are of the same version,
+	// and before the download, so an error leaves localPath as it is
+	var (
+		item     DriveItem
+		modified time.Time
+	)
+	if opts.PreserveTimestamps || verify {
+		item, err = c.getItemUncached(ctx, itemID, "id,file,fileSystemInfo,lastModifiedDateTime")
+		if err != nil {
+			return err
+		}
+	}
+	if opts.PreserveTimestamps {
+		modified, err = item.modifiedTime()
+		if err != nil {
+			return fmt.Errorf("modification time of item %s: %w", itemID, err)
+		}
+	}
+
+	var file *os.File
+	if opts.Overwrite {
+		file, err = createTemp(localPath, 0666)
+	} else {
+		file, err = os.OpenFile(localPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			file.Close()
+			os.Remove(file.Name())
+		}
+	}()
+
+	if verify {
+		err = c.downloadWithHash(ctx, itemID, item, file)
+	} else {
+		err = c.DownloadFile(ctx, itemID, file)
+	}
+	if err != nil {
+		return err
+	}
+
+	if opts.Overwrite {
+		// flush to disk before the rename makes the content visible
+		err = file.Sync()
+		if err != nil {
+			return err
+		}
+	}
+
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+
+	if opts.PreserveTimestamps {
+		err = os.Chtimes(file.Name(), modified, modified)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.Overwrite {
+		return os.Rename(file.Name(), localPath)
+	}
+
+	return nil
+}
+
+// zipEntry is a file to add to a ZIP archive.
+type zipEntry struct {
+	item DriveItem
+	name string
+
+	// tmpName is the temporary file with the content, if downloaded
+	tmpName string
+}
+
+// DownloadAsZip writes a ZIP archive with the items identified by itemIDs to w.
+// Folders are added with all of their descendants. Entries keep their folder
+// structure relative to the deepest folder that contains all of them.
+// Graph has no ZIP download, so the archive is assembled by the client:
+// the files are downloaded concurrently to temporary files, see WithDownloadConcurrency,
+// and then added to the archive in order.
+func (c *OneDriveClient) DownloadAsZip(ctx context.Context, itemIDs []string, w io.Writer) error {
+	var entries []*zipEntry
+	for _, itemID := range itemIDs {
+		item, err := c.GetItemByID(ctx, itemID)
+		if err != nil {
+			return err
+		}
+
+		files, err := c.listFiles(ctx, item)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			entries = append(entries, &zipEntry{item: file, name: file.Path()})
+		}
+	}
+	defer func() {
+		for _, entry := range entries {
+			if entry.tmpName != "" {
+				os.Remove(entry.tmpName)
+			}
+		}
+	}()
+
+	// make names relative to the common folder
+	prefix := commonDir(entries)
+	for _, entry := range entries {
+		entry.name = strings.TrimPrefix(strings.TrimPrefix(entry.name, prefix), "/")
+	}
+
+	err := c.downloadEntries(ctx, entries)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	for _, entry := range entries {
+		err = addZipEntry(zw, entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// addZipEntry adds the downloaded content of entry to zw.
+func addZipEntry(zw *zip.Writer, entry *zipEntry) error {
+	fw, err := zw.Create(entry.name)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.Open(entry.tmpName)
+	if err != nil {
+		return err
+	}
+	defer tmp.Close()
+
+	_, err = io.Copy(fw, tmp)
+
+	return err
+}
+
+// downloadEntries downloads the content of entries to temporary files,
+// using at most c.downloadConcurrency concurrent downloads. A temporary file
+// is only open while its content is downloaded, so large folders do not run
+// out of file descriptors.
+func (c *OneDriveClient) downloadEntries(ctx context.Context, entries []*zipEntry) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	sem := make(chan struct{}, c.downloadConcurrency)
+
+	for _, entry := range entries {
+		sem <- struct{}{}
+		tmp, err := os.CreateTemp("", "onedrive-zip-*")
+		if err != nil {
+			<-sem
+			cancel()
+			wg.Wait()
+			return err
+		}
+		entry.tmpName = tmp.Name()
+
+		wg.Add(1)
+		go func(entry *zipEntry, tmp *os.File) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := c.DownloadFile(ctx, entry.item.Id, tmp)
+			if closeErr := tmp.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(entry, tmp)
+	}
+	wg.Wait()
+
+	return firstErr
+}
+
+// listFiles returns item if it is a file, or all files below item if it is a folder.
+func (c *OneDriveClient) listFiles(ctx context.Context, item DriveItem) ([]DriveItem, error) {
+	if item.Folder == nil {
+		return []DriveItem{item}, nil
+	}
+
+	children, err := c.listAllChildren(ctx, item.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []DriveItem
+	for _, child := range children {
+		childFiles, err := c.listFiles(ctx, child)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, childFiles...)
+	}
+
+	return files, nil
+}
+
+// listAllChildren returns all children of the folder identified by itemID, following NextLink.
+func (c *OneDriveClient) listAllChildren(ctx context.Context, itemID string) ([]DriveItem, error) {
+	return c.listAll(ctx, c.driveURL("/items/"+url.PathEscape(itemID)+"/children"))
+}
+
+// commonDir returns the deepest folder that contains the names of all entries.
+func commonDir(entries []*zipEntry) string {
+	if len(entries) == 0 {
+		return ""
+	}
+
+	prefix := path.Dir(entries[0].name)
+	for _, entry := range entries[1:] {
+		for prefix != "/" && !strings.HasPrefix(entry.name, prefix+"/") {
+			prefix = path.Dir(prefix)
+		}
+	}
+
+	return prefix
+}
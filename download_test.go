@@ -0,0 +1,383 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bnixon67/onedrive"
+	"github.com/bnixon67/onedrive/onedrivetest"
+)
+
+// openFiles returns the number of open file descriptors of the process,
+// or -1 if it is not known.
+func openFiles() int {
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return -1
+	}
+
+	return len(fds)
+}
+
+func TestDownloadAsZip(t *testing.T) {
+	const (
+		files       = 60
+		concurrency = 2
+	)
+
+	s := onedrivetest.NewTestServer()
+	defer s.Close()
+
+	folder := s.AddDriveItem(onedrive.DriveItem{Name: "folder", Folder: &onedrive.Folder{}})
+	sub := s.AddDriveItem(onedrive.DriveItem{Name: "sub", Folder: &onedrive.Folder{},
+		ParentReference: &onedrive.ParentReference{Id: folder.Id}})
+	want := make(map[string]string)
+	for i := 0; i < files; i++ {
+		parent, name := folder.Id, fmt.Sprintf("file%d.txt", i)
+		if i%2 == 1 {
+			parent, name = sub.Id, "sub/"+fmt.Sprintf("file%d.txt", i)
+		}
+		content := fmt.Sprintf("content of file %d", i)
+		s.AddFile(parent, name[strings.LastIndex(name, "/")+1:], []byte(content))
+		want[name] = content
+	}
+
+	// temporary files must be removed and not kept open until the archive is written
+	t.Setenv("TMPDIR", t.TempDir())
+	var (
+		mu           sync.Mutex
+		maxOpenFiles int
+	)
+	baseline := openFiles()
+	countFiles := func(req *http.Request) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if n := openFiles(); n > maxOpenFiles {
+			maxOpenFiles = n
+		}
+		return nil
+	}
+
+	c := onedrivetest.NewTestClient(s,
+		onedrive.WithDownloadConcurrency(concurrency), onedrive.WithBeforeRequest(countFiles))
+
+	var buf bytes.Buffer
+	err := c.DownloadAsZip(context.Background(), []string{folder.Id}, &buf)
+	if err != nil {
+		t.Fatalf("DownloadAsZip: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("invalid ZIP archive: %v", err)
+	}
+	got := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[f.Name] = string(b)
+	}
+	if len(got) != len(want) {
+		names := make([]string, 0, len(got))
+		for name := range got {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		t.Fatalf("archive has %d entries %v, want %d", len(got), names, len(want))
+	}
+	for name, content := range want {
+		if got[name] != content {
+			t.Errorf("entry %s = %q, want %q", name, got[name], content)
+		}
+	}
+
+	left, err := filepath.Glob(filepath.Join(os.Getenv("TMPDIR"), "onedrive-zip-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 0 {
+		t.Errorf("%d temporary files left", len(left))
+	}
+
+	// each download has at most a temporary file and both ends of a connection open
+	if baseline >= 0 && maxOpenFiles-baseline > 4*concurrency {
+		t.Errorf("%d files open during the download, want at most %d", maxOpenFiles-baseline, 4*concurrency)
+	}
+}
+
+func TestDownloadAsZipNotFound(t *testing.T) {
+	s := onedrivetest.NewTestServer()
+	defer s.Close()
+	c := onedrivetest.NewTestClient(s)
+
+	err := c.DownloadAsZip(context.Background(), []string{"missing"}, io.Discard)
+	if !onedrive.IsNotFound(err) {
+		t.Errorf("err = %v, want not found", err)
+	}
+}
+
+// dirNames returns the names of the files in dir.
+func dirNames(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	return names
+}
+
+func TestDownloadToPath(t *testing.T) {
+	s := onedrivetest.NewTestServer()
+	defer s.Close()
+	c := onedrivetest.NewTestClient(s)
+	ctx := context.Background()
+
+	item := s.AddFile("", "a.txt", []byte("new content"))
+	dir := t.TempDir()
+	localPath := filepath.Join(dir, "a.txt")
+
+	err := c.DownloadToPath(ctx, item.Id, localPath, onedrive.DownloadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(localPath); string(b) != "new content" {
+		t.Errorf("content = %q, want %q", b, "new content")
+	}
+
+	// the mode of a file created with 0666 before the umask
+	ref := filepath.Join(t.TempDir(), "ref")
+	err = os.WriteFile(ref, nil, 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refInfo, _ := os.Stat(ref)
+
+	for _, overwrite := range []bool{false, true} {
+		os.Remove(localPath)
+		err = c.DownloadToPath(ctx, item.Id, localPath, onedrive.DownloadOptions{Overwrite: overwrite})
+		if err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(localPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode() != refInfo.Mode() {
+			t.Errorf("Overwrite %v: mode = %v, want %v", overwrite, info.Mode(), refInfo.Mode())
+		}
+	}
+
+	if names := dirNames(t, dir); len(names) != 1 {
+		t.Errorf("files = %v, want only a.txt", names)
+	}
+}
+
+func TestDownloadToPathExisting(t *testing.T) {
+	s := onedrivetest.NewTestServer()
+	defer s.Close()
+	c := onedrivetest.NewTestClient(s)
+	ctx := context.Background()
+
+	item := s.AddFile("", "a.txt", []byte("new content"))
+	corrupt := s.AddFile("", "corrupt.txt", []byte("new content"))
+	corrupt.File.Hashes = &onedrive.Hashes{QuickXorHash: "AAAAAAAAAAAAAAAAAAAAAAAAAAA="}
+	s.AddDriveItem(corrupt)
+
+	tests := []struct {
+		name    string
+		itemID  string
+		opts    onedrive.DownloadOptions
+		want    string // content of the local file after the download
+		wantErr func(error) bool
+	}{
+		{"no overwrite", item.Id, onedrive.DownloadOptions{},
+			"old content", func(err error) bool { return errors.Is(err, fs.ErrExist) }},
+		{"overwrite", item.Id, onedrive.DownloadOptions{Overwrite: true},
+			"new content", func(err error) bool { return err == nil }},
+		{"overwrite not found", "missing", onedrive.DownloadOptions{Overwrite: true},
+			"old content", onedrive.IsNotFound},
+		{"overwrite corrupt", corrupt.Id, onedrive.DownloadOptions{Overwrite: true, VerifyIntegrity: true},
+			"old content", func(err error) bool {
+				var integrityErr *onedrive.IntegrityError
+				return errors.As(err, &integrityErr)
+			}},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		localPath := filepath.Join(dir, "a.txt")
+		err := os.WriteFile(localPath, []byte("old content"), 0666)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = c.DownloadToPath(ctx, tt.itemID, localPath, tt.opts)
+		if !tt.wantErr(err) {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		}
+		if b, _ := os.ReadFile(localPath); string(b) != tt.want {
+			t.Errorf("%s: content = %q, want %q", tt.name, b, tt.want)
+		}
+		if names := dirNames(t, dir); len(names) != 1 {
+			t.Errorf("%s: files = %v, want only a.txt", tt.name, names)
+		}
+	}
+}
+
+func TestDownloadToPathFailed(t *testing.T) {
+	s := onedrivetest.NewTestServer()
+	defer s.Close()
+	c := onedrivetest.NewTestClient(s)
+
+	dir := t.TempDir()
+	err := c.DownloadToPath(context.Background(), "missing", filepath.Join(dir, "a.txt"), onedrive.DownloadOptions{})
+	if !onedrive.IsNotFound(err) {
+		t.Errorf("err = %v, want not found", err)
+	}
+	if names := dirNames(t, dir); len(names) != 0 {
+		t.Errorf("files = %v, want none", names)
+	}
+}
+
+func TestDownloadToPathPreserveTimestamps(t *testing.T) {
+	s := onedrivetest.NewTestServer()
+	defer s.Close()
+	c := onedrivetest.NewTestClient(s)
+	ctx := context.Background()
+
+	item := s.AddFile("", "a.txt", []byte("new content"))
+	item.FileSystemInfo.LastModifiedDateTime = "2019-03-14T15:09:26Z"
+	s.AddDriveItem(item)
+	want := time.Date(2019, 3, 14, 15, 9, 26, 0, time.UTC)
+
+	invalid := s.AddFile("", "invalid.txt", []byte("new content"))
+	invalid.FileSystemInfo.LastModifiedDateTime = "yesterday"
+	s.AddDriveItem(invalid)
+
+	for _, overwrite := range []bool{false, true} {
+		localPath := filepath.Join(t.TempDir(), "a.txt")
+		if overwrite {
+			err := os.WriteFile(localPath, []byte("old content"), 0666)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		opts := onedrive.DownloadOptions{PreserveTimestamps: true, Overwrite: overwrite}
+		err := c.DownloadToPath(ctx, item.Id, localPath, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(localPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !info.ModTime().Equal(want) {
+			t.Errorf("Overwrite %v: modification time = %v, want %v", overwrite, info.ModTime(), want)
+		}
+
+		// an invalid time fails before the local file is changed
+		err = c.DownloadToPath(ctx, invalid.Id, localPath, opts)
+		if err == nil || !strings.Contains(err.Error(), "yesterday") {
+			t.Errorf("Overwrite %v: err = %v, want parse error", overwrite, err)
+		}
+		if b, _ := os.ReadFile(localPath); string(b) != "new content" {
+			t.Errorf("Overwrite %v: content = %q, want %q", overwrite, b, "new content")
+		}
+	}
+}
+
+func TestDownloadToPathRetrievesItemOnce(t *testing.T) {
+	s := onedrivetest.NewTestServer()
+	defer s.Close()
+
+	// without FileSystemInfo, the time recorded by Graph is used
+	item := s.AddFile("", "a.txt", []byte("new content"))
+	item.LastModifiedDateTime = "2019-03-14T15:09:26Z"
+	s.AddDriveItem(item)
+	want := time.Date(2019, 3, 14, 15, 9, 26, 0, time.UTC)
+
+	corrupt := s.AddFile("", "corrupt.txt", []byte("new content"))
+	corrupt.File.Hashes = &onedrive.Hashes{QuickXorHash: "AAAAAAAAAAAAAAAAAAAAAAAAAAA="}
+	s.AddDriveItem(corrupt)
+
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	c := onedrivetest.NewTestClient(s, onedrive.WithBeforeRequest(func(req *http.Request) error {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, req.URL.Path)
+		return nil
+	}))
+	ctx := context.Background()
+	opts := onedrive.DownloadOptions{PreserveTimestamps: true, VerifyIntegrity: true}
+
+	localPath := filepath.Join(t.TempDir(), "a.txt")
+	err := c.DownloadToPath(ctx, item.Id, localPath, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(localPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(want) {
+		t.Errorf("modification time = %v, want %v", info.ModTime(), want)
+	}
+
+	itemPath := "/v1.0/me/drive/items/" + item.Id
+	if len(requests) != 2 || requests[0] != itemPath || requests[1] != itemPath+"/content" {
+		t.Errorf("requests = %v, want %s and its content", requests, itemPath)
+	}
+
+	// the hash of the same retrieval is verified
+	err = c.DownloadToPath(ctx, corrupt.Id, filepath.Join(t.TempDir(), "corrupt.txt"), opts)
+	if !errors.Is(err, onedrive.ErrIntegrityCheckFailed) {
+		t.Errorf("err = %v, want integrity check failed", err)
+	}
+}
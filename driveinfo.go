@@ -0,0 +1,129 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+)
+
+// Feature is a capability of Graph that is only available on some types of drives.
+type Feature string
+
+const (
+	// FeatureVersionHistory is the version history of files.
+	FeatureVersionHistory Feature = "versionHistory"
+
+	// FeatureFolderDelta is the delta API on folders other than the root.
+	FeatureFolderDelta Feature = "folderDelta"
+
+	// FeatureActivities is the activity feed of GetDriveActivities.
+	FeatureActivities Feature = "activities"
+
+	// FeatureCheckout is checking out and checking in files, see GetPublicationInfo.
+	FeatureCheckout Feature = "checkout"
+
+	// FeatureComments is the comments of ListComments and AddComment.
+	FeatureComments Feature = "comments"
+
+	// FeatureLinkPassword is a password on sharing links.
+	FeatureLinkPassword Feature = "linkPassword"
+)
+
+// featureDriveTypes lists the drive types that support each feature.
+var featureDriveTypes = map[Feature][]string{
+	FeatureVersionHistory: {"personal", "business", "documentLibrary"},
+	FeatureFolderDelta:    {"personal"},
+	FeatureActivities:     {"business", "documentLibrary"},
+	FeatureCheckout:       {"business", "documentLibrary"},
+	FeatureComments:       {"personal"},
+	FeatureLinkPassword:   {"personal"},
+}
+
+// features lists all features in a stable order.
+var features = []Feature{
+	FeatureVersionHistory,
+	FeatureFolderDelta,
+	FeatureActivities,
+	FeatureCheckout,
+	FeatureComments,
+	FeatureLinkPassword,
+}
+
+// FeatureSupported reports whether feature is available on drive, based on its DriveType.
+func FeatureSupported(drive Drive, feature Feature) bool {
+	for _, driveType := range featureDriveTypes[feature] {
+		if drive.DriveType == driveType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MaxFileSize is the maximum size of a file in OneDrive and SharePoint.
+const MaxFileSize int64 = 250 << 30
+
+// DriveInfo describes what the drive of the client supports.
+type DriveInfo struct {
+	// The drive itself.
+	Drive Drive
+
+	// True for OneDrive personal.
+	IsPersonal bool
+
+	// True for OneDrive for Business and SharePoint document libraries.
+	IsBusiness bool
+
+	// True if the versions of files are kept.
+	HasVersionHistory bool
+
+	// Maximum size of a file in bytes.
+	MaxFileSize int64
+
+	// The features available on the drive.
+	SupportedFeatures []string
+}
+
+// GetSystemDriveInfo retrieves the drive of the client and describes what it supports.
+func (c *OneDriveClient) GetSystemDriveInfo(ctx context.Context) (info DriveInfo, err error) {
+	body, err := c.get(ctx, c.driveURL(""))
+	if err != nil {
+		return DriveInfo{}, err
+	}
+
+	var drive Drive
+	err = c.unmarshal(body, &drive)
+	if err != nil {
+		return DriveInfo{}, err
+	}
+
+	info = DriveInfo{
+		Drive:             drive,
+		IsPersonal:        drive.DriveType == "personal",
+		IsBusiness:        drive.DriveType == "business" || drive.DriveType == "documentLibrary",
+		HasVersionHistory: FeatureSupported(drive, FeatureVersionHistory),
+		MaxFileSize:       MaxFileSize,
+	}
+	for _, feature := range features {
+		if FeatureSupported(drive, feature) {
+			info.SupportedFeatures = append(info.SupportedFeatures, string(feature))
+		}
+	}
+
+	return info, nil
+}
@@ -17,7 +17,12 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>.
 
 package onedrive
 
-import "fmt"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
 
 type InnerError struct {
 	RequestId string `json:"request-id,omitempty"`
@@ -32,25 +37,105 @@ type Err struct {
 
 type RespError struct {
 	Err *Err `json:"error,omitempty"`
+
+	// RequestID identifies the request when contacting Microsoft support.
+	// It is taken from the client-request-id response header,
+	// falling back to InnerError.RequestId.
+	RequestID string `json:"-"`
+
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int `json:"-"`
+}
+
+// parseRespError decodes body, the body of a Graph error response with statusCode.
+// requestID, from the client-request-id header, falls back to InnerError.RequestId.
+func parseRespError(body []byte, statusCode int, requestID string) (*RespError, error) {
+	resError := &RespError{}
+	err := json.Unmarshal(body, resError)
+	if err != nil {
+		return nil, err
+	}
+
+	resError.RequestID = requestID
+	resError.StatusCode = statusCode
+	if resError.RequestID == "" && resError.Err != nil && resError.Err.InnerError != nil {
+		resError.RequestID = resError.Err.InnerError.RequestId
+	}
+
+	return resError, nil
 }
 
+// HTTPStatus returns the HTTP status code of the response.
+func (e *RespError) HTTPStatus() int {
+	return e.StatusCode
+}
+
+// HTTPStatusCode returns the HTTP status code of the first RespError in the chain of err.
+// ok is false if err does not wrap a RespError.
+func HTTPStatusCode(err error) (code int, ok bool) {
+	var respErr *RespError
+	if !errors.As(err, &respErr) {
+		return 0, false
+	}
+
+	return respErr.StatusCode, true
+}
+
+// Error formats the Graph error, tolerating a missing error or inner error.
 func (e *RespError) Error() string {
+	var code, message, requestID, date string
+	if e.Err != nil {
+		code, message = e.Err.Code, e.Err.Message
+		if e.Err.InnerError != nil {
+			requestID, date = e.Err.InnerError.RequestId, e.Err.InnerError.Date
+		}
+	}
+	if requestID == "" {
+		requestID = e.RequestID
+	}
+
 	return fmt.Sprintf("Code: %s Message: %s RequestId: %s Date: %s\n",
-		e.Err.Code, e.Err.Message,
-		e.Err.InnerError.RequestId, e.Err.InnerError.Date)
+		code, message, requestID, date)
 }
 
-func codeIsError(code int) bool {
-	// Microsoft Graph error responses and resource types
-	// https://docs.microsoft.com/en-us/graph/errors
-	var errorCodes = []int{400, 401, 403, 404, 405, 406, 409, 410,
-		411, 412, 413, 415, 416, 422, 423, 429, 500, 501, 503,
-		504, 507, 509}
-
-	for _, n := range errorCodes {
-		if code == n {
-			return true
-		}
+// ErrNotFound matches, with errors.Is, a Graph error for a missing resource.
+var ErrNotFound = errors.New("not found")
+
+// Is reports whether e matches target, so errors.Is(err, ErrNotFound) reports
+// a Graph error for a missing resource, by its code or its 404 status code.
+func (e *RespError) Is(target error) bool {
+	if target != ErrNotFound {
+		return false
+	}
+	if e.StatusCode == http.StatusNotFound {
+		return true
 	}
-	return false
+
+	return e.Err != nil && (e.Err.Code == "itemNotFound" || e.Err.Code == "ResourceNotFound")
+}
+
+// IsNotFound reports whether err is, or wraps, a Graph error for a missing resource.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// isNameConflict reports whether err is a Graph error for an item name that already exists.
+func isNameConflict(err error) bool {
+	var respErr *RespError
+	return errors.As(err, &respErr) && respErr.Err != nil &&
+		respErr.Err.Code == "nameAlreadyExists"
+}
+
+// errorCodes are the status codes of Microsoft Graph error responses.
+// https://docs.microsoft.com/en-us/graph/errors
+var errorCodes = map[int]bool{
+	400: true, 401: true, 403: true, 404: true, 405: true, 406: true,
+	409: true, 410: true, 411: true, 412: true, 413: true, 415: true,
+	416: true, 422: true, 423: true, 429: true, 500: true, 501: true,
+	503: true, 504: true, 507: true, 509: true,
+}
+
+// codeIsError reports whether code is a status code of a Graph error response.
+func codeIsError(code int) bool {
+	return errorCodes[code]
 }
@@ -0,0 +1,131 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func FuzzParseRespError(f *testing.F) {
+	f.Add([]byte(`{"error":{"code":"itemNotFound","message":"Item does not exist","innerError":{"request-id":"inner-id","date":"2024-05-03T09:14:55"}}}`), 404, "")
+	f.Add([]byte(`{"error":{"code":"activityLimitReached","message":"throttled"}}`), 429, "header-id")
+	f.Add([]byte(`{"error":{"code":"itemNotFound","mess`), 404, "")
+	f.Add([]byte(`<html><body>502 Bad Gateway</body></html>`), 502, "")
+	f.Add([]byte(``), 500, "")
+	f.Add([]byte(`null`), 400, "")
+	f.Add([]byte(`{"error":null}`), 400, "")
+	f.Add([]byte(`{"error":"not an object"}`), 400, "")
+	f.Add([]byte(`{"error":{"innerError":null}}`), 503, "header-id")
+
+	f.Fuzz(func(t *testing.T, body []byte, statusCode int, requestID string) {
+		resError, err := parseRespError(body, statusCode, requestID)
+		if err != nil {
+			if resError != nil {
+				t.Errorf("parseRespError returned %+v with error %v", resError, err)
+			}
+			return
+		}
+
+		if resError.StatusCode != statusCode {
+			t.Errorf("StatusCode = %d, want %d", resError.StatusCode, statusCode)
+		}
+		if requestID != "" && resError.RequestID != requestID {
+			t.Errorf("RequestID = %q, want %q", resError.RequestID, requestID)
+		}
+		if requestID == "" && resError.Err != nil && resError.Err.InnerError != nil &&
+			resError.RequestID != resError.Err.InnerError.RequestId {
+			t.Errorf("RequestID = %q, want inner %q", resError.RequestID, resError.Err.InnerError.RequestId)
+		}
+		if !strings.HasPrefix(resError.Error(), "Code: ") {
+			t.Errorf("Error() = %q", resError.Error())
+		}
+	})
+}
+
+func TestCodeIsError(t *testing.T) {
+	// https://docs.microsoft.com/en-us/graph/errors
+	documented := []int{
+		400, 401, 403, 404, 405, 406, 409, 410, 411, 412, 413, 415,
+		416, 422, 423, 429, 500, 501, 503, 504, 507, 509,
+	}
+	for _, code := range documented {
+		if !codeIsError(code) {
+			t.Errorf("codeIsError(%d) = false, want true", code)
+		}
+	}
+	if len(errorCodes) != len(documented) {
+		t.Errorf("errorCodes has %d codes, want the %d documented", len(errorCodes), len(documented))
+	}
+
+	for _, code := range []int{100, 200, 201, 202, 204, 206, 301, 302, 304, 307} {
+		if codeIsError(code) {
+			t.Errorf("codeIsError(%d) = true, want false", code)
+		}
+	}
+}
+
+func TestCodeIsErrorEdgeCases(t *testing.T) {
+	tests := []struct {
+		code int
+		want bool
+	}{
+		{0, false},
+		{-1, false},
+		{399, false},
+		{400, true},
+		{402, false},
+		{499, false},
+		{509, true},
+		{510, false},
+		{999, false},
+	}
+
+	for _, tt := range tests {
+		if got := codeIsError(tt.code); got != tt.want {
+			t.Errorf("codeIsError(%d) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestRespErrorIsNotFound(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *RespError
+		want bool
+	}{
+		{"itemNotFound", &RespError{Err: &Err{Code: "itemNotFound"}, StatusCode: 404}, true},
+		{"ResourceNotFound", &RespError{Err: &Err{Code: "ResourceNotFound"}}, true},
+		{"404 with another code", &RespError{Err: &Err{Code: "notFound"}, StatusCode: 404}, true},
+		{"404 without error", &RespError{StatusCode: 404}, true},
+		{"other code", &RespError{Err: &Err{Code: "accessDenied"}, StatusCode: 403}, false},
+		{"without error", &RespError{StatusCode: 500}, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsNotFound(fmt.Errorf("wrapped: %w", tt.err)); got != tt.want {
+			t.Errorf("%s: IsNotFound = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if errors.Is(&RespError{StatusCode: 404}, ErrUnknownField) {
+		t.Error("a 404 RespError matches ErrUnknownField")
+	}
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Mirror_url copies the content of a URL into a OneDrive file without storing it locally.
+//
+// Usage:
+//
+//	go run ./examples/mirror_url [-token file] url remotePath
+//
+// The Content-Length of the source picks the upload method: up to 4 MB is uploaded
+// with UploadSmallFile, anything larger is streamed in chunks with UploadLargeFile.
+// A source without Content-Length is spooled to a temporary file to learn its size.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+
+	"github.com/bnixon67/onedrive"
+)
+
+// progress counts the bytes written to it.
+type progress struct {
+	n, total int64
+}
+
+func (p *progress) Write(b []byte) (int, error) {
+	p.n += int64(len(b))
+	if p.total > 0 {
+		fmt.Printf("\r%d of %d bytes", p.n, p.total)
+	} else {
+		fmt.Printf("\r%d bytes", p.n)
+	}
+
+	return len(b), nil
+}
+
+// spool copies r to a temporary file and returns the file, positioned at the start, and its size.
+func spool(r io.Reader) (*os.File, int64, error) {
+	f, err := os.CreateTemp("", "mirror-*")
+	if err != nil {
+		return nil, 0, err
+	}
+	os.Remove(f.Name()) // removed when closed on Unix-like systems
+
+	size, err := io.Copy(f, r)
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+
+	return f, size, nil
+}
+
+func main() {
+	tokenFile := flag.String("token", ".token.json", "token file")
+	flag.Parse()
+	if flag.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "usage: mirror_url [-token file] url remotePath")
+		os.Exit(2)
+	}
+	sourceURL, remotePath := flag.Arg(0), path.Clean("/"+flag.Arg(1))
+
+	ctx := context.Background()
+	// uploading needs a token with write access
+	client, err := onedrive.NewWithConfig(onedrive.Config{
+		TokenFile: *tokenFile,
+		Scopes:    []string{"Files.ReadWrite.All", "offline_access"},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	resp, err := http.Get(sourceURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		log.Fatalf("GET %s: %s", sourceURL, resp.Status)
+	}
+
+	var (
+		body io.Reader = resp.Body
+		size           = resp.ContentLength
+	)
+	if size < 0 {
+		// unknown length, so spool the content to learn the size
+		f, n, err := spool(resp.Body)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		body, size = f, n
+	}
+
+	// report progress as the content is read by the upload
+	body = io.TeeReader(body, &progress{total: size})
+
+	var item onedrive.DriveItem
+	if size <= onedrive.SmallFileMaxSize {
+		item, err = client.UploadSmallFile(ctx, remotePath, body, resp.Header.Get("Content-Type"))
+	} else {
+		item, err = client.UploadLargeFile(ctx, remotePath, body, size, onedrive.UploadLargeFileOptions{})
+	}
+	fmt.Println()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("uploaded %s (%d bytes) as %s\n", item.Name, item.Size, item.WebURL)
+}
@@ -0,0 +1,425 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Sync incrementally synchronizes a local folder with a OneDrive folder.
+//
+// Usage:
+//
+//	go run ./examples/sync [-token file] [-state file] [-conflict newer|local|remote|skip|both] localDir remoteFolder
+//
+// The first run enumerates the drive with the delta API, later runs only fetch
+// the changes since the delta link saved in the state file, a SyncState, so use
+// a different state file for each folder. New or changed remote files are
+// downloaded, then local files modified since the last run are uploaded.
+// Files changed on both sides, or that differ on the first run, are resolved
+// with the -conflict resolver, by default the newer file wins; with both, the local
+// file is renamed and uploaded as a new file. Deletions are reported but not applied.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bnixon67/onedrive"
+)
+
+// node is an item of the drive, to find the path of the changed files.
+type node struct {
+	name     string
+	parentID string
+	root     bool
+}
+
+// syncer synchronizes localDir with remoteFolder.
+type syncer struct {
+	client       *onedrive.OneDriveClient
+	state        onedrive.SyncState
+	localDir     string
+	remoteFolder string
+	resolver     onedrive.FileConflictResolver
+
+	// the items of the drive seen during this run, by ID
+	nodes map[string]node
+
+	// the hashes of the remote files changed during this run, by relative path
+	remoteHashes map[string]string
+
+	// the relative paths of the conflicting files not to upload
+	skipped map[string]bool
+}
+
+// remotePath returns the path of the item identified by id relative to the root of the drive.
+// Items not seen in the changes are retrieved.
+func (s *syncer) remotePath(ctx context.Context, id string) (string, error) {
+	var names []string
+	for {
+		n, ok := s.nodes[id]
+		if !ok {
+			item, err := s.client.GetItemByID(ctx, id)
+			if err != nil {
+				return "", err
+			}
+			n = newNode(item)
+			s.nodes[id] = n
+		}
+		if n.root || n.parentID == "" {
+			break
+		}
+		names = append([]string{n.name}, names...)
+		id = n.parentID
+	}
+
+	return "/" + path.Join(names...), nil
+}
+
+// newNode returns the node of item.
+func newNode(item onedrive.DriveItem) node {
+	n := node{name: item.Name, root: item.Root != nil}
+	if item.ParentReference != nil {
+		n.parentID = item.ParentReference.Id
+	}
+
+	return n
+}
+
+// relPath returns remotePath relative to the synchronized folder, or false if it is not in the folder.
+func (s *syncer) relPath(remotePath string) (string, bool) {
+	prefix := strings.TrimSuffix(s.remoteFolder, "/") + "/"
+	if !strings.HasPrefix(remotePath, prefix) {
+		return "", false
+	}
+
+	return strings.TrimPrefix(remotePath, prefix), true
+}
+
+// localChanged reports whether the local file at fileName was modified since the last run.
+func (s *syncer) localChanged(fileName string) (bool, error) {
+	info, err := os.Stat(fileName)
+	if err != nil {
+		return false, err
+	}
+
+	return info.ModTime().After(s.state.LastSyncTime), nil
+}
+
+// download fetches the remote changes since the saved delta link and
+// downloads the new or changed files in the folder.
+func (s *syncer) download(ctx context.Context) error {
+	items, deltaLink, err := s.client.GetDelta(ctx, s.state.DeltaToken)
+	if err != nil && s.state.DeltaToken != "" {
+		// the delta link may have expired, so enumerate the drive again
+		log.Printf("delta failed, resynchronizing: %v", err)
+		items, deltaLink, err = s.client.GetDelta(ctx, "")
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if item.Deleted == nil {
+			s.nodes[item.Id] = newNode(item)
+		}
+	}
+
+	for _, item := range items {
+		if item.Deleted != nil {
+			if _, ok := s.state.ItemHashes[item.Id]; ok {
+				fmt.Println("deleted remotely, not applied:", item.Name)
+				delete(s.state.ItemHashes, item.Id)
+			}
+			continue
+		}
+		if item.File == nil {
+			continue
+		}
+
+		remotePath, err := s.remotePath(ctx, item.Id)
+		if err != nil {
+			return err
+		}
+		rel, ok := s.relPath(remotePath)
+		if !ok {
+			continue
+		}
+
+		err = s.downloadFile(ctx, item, rel)
+		if err != nil {
+			return err
+		}
+	}
+	s.state.DeltaToken = deltaLink
+
+	return nil
+}
+
+// downloadFile downloads item, a changed remote file at rel, unless the local file has the same
+// content or was also changed.
+func (s *syncer) downloadFile(ctx context.Context, item onedrive.DriveItem, rel string) error {
+	hash := ""
+	if item.File.Hashes != nil {
+		hash = item.File.Hashes.QuickXorHash
+	}
+	s.remoteHashes[rel] = hash
+	if hash != "" && s.state.ItemHashes[item.Id] == hash {
+		// the content is already synchronized, e.g., uploaded by the last run
+		return nil
+	}
+
+	fileName := filepath.Join(s.localDir, filepath.FromSlash(rel))
+	changed, err := s.localChanged(fileName)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err == nil {
+		localHash, err := onedrive.QuickXorHashFile(fileName)
+		if err != nil {
+			return err
+		}
+		if localHash == hash {
+			s.state.ItemHashes[item.Id] = hash
+			return nil
+		}
+		if changed {
+			download, err := s.resolveConflict(item, rel, fileName, localHash)
+			if err != nil || !download {
+				return err
+			}
+		}
+	}
+
+	fmt.Println("download:", rel)
+	err = os.MkdirAll(filepath.Dir(fileName), 0755)
+	if err != nil {
+		return err
+	}
+	err = s.client.DownloadToPath(ctx, item.Id, fileName, onedrive.DownloadOptions{
+		Overwrite:          true,
+		PreserveTimestamps: true,
+		VerifyIntegrity:    true,
+	})
+	if err != nil {
+		return err
+	}
+	s.state.ItemHashes[item.Id] = hash
+
+	return nil
+}
+
+// resolveConflict resolves a conflict between item and the local file at fileName,
+// both changed since the last run, and reports whether to download item.
+func (s *syncer) resolveConflict(item onedrive.DriveItem, rel, fileName, localHash string) (bool, error) {
+	info, err := os.Stat(fileName)
+	if err != nil {
+		return false, err
+	}
+
+	local := onedrive.LocalFileInfo{
+		Path:         fileName,
+		Size:         info.Size(),
+		ModTime:      info.ModTime(),
+		QuickXorHash: localHash,
+	}
+	decision, err := s.resolver.Resolve(local, item)
+	if err != nil {
+		return false, err
+	}
+
+	switch decision {
+	case onedrive.ConflictUseLocal:
+		fmt.Println("changed on both sides, keeping local:", rel)
+		return false, nil
+	case onedrive.ConflictUseRemote:
+		fmt.Println("changed on both sides, keeping remote:", rel)
+		return true, nil
+	case onedrive.ConflictKeepBoth:
+		// the renamed local file is uploaded as a new file
+		keptName, err := conflictName(fileName)
+		if err != nil {
+			return false, err
+		}
+		fmt.Println("changed on both sides, keeping both:", rel, "and", filepath.Base(keptName))
+		return true, os.Rename(fileName, keptName)
+	}
+
+	fmt.Println("changed on both sides, skipped:", rel)
+	s.skipped[rel] = true
+
+	return false, nil
+}
+
+// conflictName returns an unused name for the local version of fileName,
+// with " (local)" and a number if needed added before the extension.
+func conflictName(fileName string) (string, error) {
+	ext := filepath.Ext(fileName)
+	base := strings.TrimSuffix(fileName, ext)
+	for i := 1; ; i++ {
+		name := base + " (local)" + ext
+		if i > 1 {
+			name = fmt.Sprintf("%s (local %d)%s", base, i, ext)
+		}
+
+		_, err := os.Lstat(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			return name, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// upload uploads the local files modified since the last run to the folder.
+func (s *syncer) upload(ctx context.Context) error {
+	folders := make(map[string]bool)
+
+	return filepath.WalkDir(s.localDir, func(fileName string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(s.localDir, fileName)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if s.skipped[rel] {
+			return nil
+		}
+
+		changed, err := s.localChanged(fileName)
+		if err != nil || !changed {
+			return err
+		}
+
+		hash, err := onedrive.QuickXorHashFile(fileName)
+		if err != nil {
+			return err
+		}
+		if s.remoteHashes[rel] == hash {
+			// already the same content
+			return nil
+		}
+
+		// create the parent folder, if needed
+		remotePath := path.Join(s.remoteFolder, rel)
+		dir := path.Dir(remotePath)
+		if !folders[dir] {
+			_, err = s.client.CreateFolderByPath(ctx, dir)
+			if err != nil {
+				return err
+			}
+			folders[dir] = true
+		}
+
+		fmt.Println("upload:", rel)
+		f, err := os.Open(fileName)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		item, err := s.client.UploadFile(ctx, remotePath, f, -1, onedrive.UploadOptions{})
+		if err != nil {
+			return err
+		}
+		s.state.ItemHashes[item.Id] = hash
+
+		return nil
+	})
+}
+
+func main() {
+	tokenFile := flag.String("token", ".token.json", "token file")
+	stateFile := flag.String("state", ".sync-state.json", "state file")
+	conflict := flag.String("conflict", "newer", "resolution of files changed on both sides: newer, local, remote, skip, or both")
+	flag.Parse()
+	resolvers := map[string]onedrive.FileConflictResolver{
+		"newer":  onedrive.NewerWinsResolver,
+		"local":  onedrive.LocalWinsResolver,
+		"remote": onedrive.RemoteWinsResolver,
+		"skip":   onedrive.SkipResolver,
+		"both": onedrive.ConflictResolverFunc(func(onedrive.LocalFileInfo, onedrive.DriveItem) (onedrive.ConflictDecision, error) {
+			return onedrive.ConflictKeepBoth, nil
+		}),
+	}
+	resolver, ok := resolvers[*conflict]
+	if flag.NArg() != 2 || !ok {
+		fmt.Fprintln(os.Stderr, "usage: sync [-token file] [-state file] [-conflict newer|local|remote|skip|both] localDir remoteFolder")
+		os.Exit(2)
+	}
+
+	// uploading needs a token with write access
+	client, err := onedrive.NewWithConfig(onedrive.Config{
+		TokenFile: *tokenFile,
+		Scopes:    []string{"Files.ReadWrite.All", "offline_access"},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	state, err := onedrive.LoadSyncState(*stateFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Fatal(err)
+	}
+	if state.ItemHashes == nil {
+		state.ItemHashes = make(map[string]string)
+	}
+
+	s := &syncer{
+		client:       client,
+		state:        state,
+		localDir:     flag.Arg(0),
+		remoteFolder: path.Clean("/" + flag.Arg(1)),
+		resolver:     resolver,
+		nodes:        make(map[string]node),
+		remoteHashes: make(map[string]string),
+		skipped:      make(map[string]bool),
+	}
+
+	ctx := context.Background()
+	_, err = client.CreateFolderByPath(ctx, s.remoteFolder)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// local files modified from now on are uploaded by the next run
+	start := time.Now()
+
+	err = s.download(ctx)
+	if err == nil {
+		err = s.upload(ctx)
+		if err == nil {
+			s.state.LastSyncTime = start
+		}
+	}
+
+	// save the progress, even after an error
+	if saveErr := onedrive.SaveSyncState(*stateFile, s.state); saveErr != nil {
+		log.Fatal(saveErr)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
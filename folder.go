@@ -0,0 +1,226 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrNotFolder is returned when an item that must be a folder is a file.
+var ErrNotFolder = errors.New("not a folder")
+
+// newFolder is the request body to create a folder.
+type newFolder struct {
+	Name             string   `json:"name"`
+	Folder           struct{} `json:"folder"`
+	ConflictBehavior string   `json:"@microsoft.graph.conflictBehavior,omitempty"`
+}
+
+// CreateFolder creates a folder named name in the folder identified by parentID.
+// It fails if an item with the same name already exists.
+func (c *OneDriveClient) CreateFolder(ctx context.Context, parentID, name string) (driveItem DriveItem, err error) {
+	err = c.doJSON(ctx, http.MethodPost,
+		c.driveURL("/items/"+url.PathEscape(parentID)+"/children"),
+		newFolder{Name: name, ConflictBehavior: "fail"}, &driveItem)
+
+	return driveItem, err
+}
+
+// batchFolderThreshold is the number of missing folders above which
+// CreateFolderByPath creates them with a single batch request.
+const batchFolderThreshold = 3
+
+// CreateFolderByPath creates the folder at path relative to the root of the drive,
+// including any missing intermediate folders, and returns it. If the folder
+// already exists, it is returned. More than three missing folders are created
+// with JSON batching to save round trips.
+func (c *OneDriveClient) CreateFolderByPath(ctx context.Context, path string) (DriveItem, error) {
+	var parts []string
+	for _, part := range strings.Split(path, "/") {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	// find the deepest existing folder, starting with the full path
+	var (
+		existing DriveItem
+		n        int
+	)
+	for n = len(parts); n >= 0; n-- {
+		item, err := c.GetItemByPath(ctx, strings.Join(parts[:n], "/"))
+		if err == nil {
+			existing = item
+			break
+		}
+		if !IsNotFound(err) {
+			return DriveItem{}, err
+		}
+	}
+	if n < 0 {
+		return DriveItem{}, fmt.Errorf("root of drive not found")
+	}
+	if existing.Folder == nil && existing.Root == nil {
+		return DriveItem{}, fmt.Errorf("%w: %s", ErrNotFolder, strings.Join(parts[:n], "/"))
+	}
+	if n == len(parts) {
+		return existing, nil
+	}
+
+	missing := len(parts) - n
+	if missing > batchFolderThreshold {
+		return c.createFoldersBatch(ctx, parts, n)
+	}
+
+	// create each missing folder in its parent
+	parentID := existing.Id
+	var item DriveItem
+	for i := n; i < len(parts); i++ {
+		var err error
+		item, err = c.CreateFolder(ctx, parentID, parts[i])
+		if isNameConflict(err) {
+			// created concurrently by someone else
+			item, err = c.GetItemByPath(ctx, strings.Join(parts[:i+1], "/"))
+		}
+		if err != nil {
+			return DriveItem{}, err
+		}
+		parentID = item.Id
+	}
+
+	return item, nil
+}
+
+// createFoldersBatch creates the folders parts[n:], where parts[:n] already exists,
+// using batch requests in which each folder depends on the creation of its parent.
+func (c *OneDriveClient) createFoldersBatch(ctx context.Context, parts []string, n int) (DriveItem, error) {
+	var item DriveItem
+
+	for start := n; start < len(parts); start += maxBatchRequests {
+		end := start + maxBatchRequests
+		if end > len(parts) {
+			end = len(parts)
+		}
+
+		var requests []batchRequest
+		for i := start; i < end; i++ {
+			req := batchRequest{
+				Id:      fmt.Sprint(i),
+				Method:  http.MethodPost,
+				URL:     c.drive() + itemByPath(strings.Join(parts[:i], "/")) + "/children",
+				Body:    newFolder{Name: parts[i], ConflictBehavior: "fail"},
+				Headers: map[string]string{"Content-Type": "application/json"},
+			}
+			if i > start {
+				req.DependsOn = []string{fmt.Sprint(i - 1)}
+			}
+			requests = append(requests, req)
+		}
+
+		responses, err := c.batch(ctx, requests)
+		if err != nil {
+			return DriveItem{}, err
+		}
+
+		// report the first failure in path order
+		for _, req := range requests {
+			err = responses[req.Id].decode(&item)
+			if err != nil {
+				return DriveItem{}, err
+			}
+		}
+	}
+
+	return item, nil
+}
+
+// GetFolderSize returns the total size of the files in the folder identified by folderID
+// and all of its subfolders. The sizes of the folders themselves are not counted.
+// The files are enumerated with the delta API on the root folder and on OneDrive
+// Personal, where delta on a folder returns all of its descendants in one paginated
+// call, and by listing every subfolder otherwise. ErrNotFolder is returned for a file.
+func (c *OneDriveClient) GetFolderSize(ctx context.Context, folderID string) (size int64, err error) {
+	files, err := c.folderFiles(ctx, folderID)
+	if err != nil {
+		return 0, err
+	}
+	for _, file := range files {
+		size += file.Size
+	}
+
+	return size, nil
+}
+
+// GetItemsModifiedSince returns the files in the folder identified by folderID and all
+// of its subfolders that were modified after since. Neither the delta API nor listing
+// children can filter by time, so the files are enumerated like GetFolderSize and
+// filtered by LastModifiedDateTime. Graph updates LastModifiedDateTime asynchronously,
+// so a file modified shortly before since may be missed; use GetDelta to track every change.
+func (c *OneDriveClient) GetItemsModifiedSince(ctx context.Context, folderID string, since time.Time) (items []DriveItem, err error) {
+	files, err := c.folderFiles(ctx, folderID)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		modified, err := time.Parse(time.RFC3339, file.LastModifiedDateTime)
+		if err == nil && modified.After(since) {
+			items = append(items, file)
+		}
+	}
+
+	return items, nil
+}
+
+// folderFiles returns the files in the folder identified by folderID and all of its subfolders,
+// using delta where it returns all descendants of the folder, see GetFolderSize.
+func (c *OneDriveClient) folderFiles(ctx context.Context, folderID string) (files []DriveItem, err error) {
+	folder, err := c.GetItemByID(ctx, folderID)
+	if err != nil {
+		return nil, err
+	}
+	if folder.Folder == nil && folder.Root == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFolder, folder.Name)
+	}
+
+	personal := folder.ParentReference != nil && folder.ParentReference.DriveType == "personal"
+	if folder.Root == nil && !personal {
+		return c.listFiles(ctx, folder)
+	}
+
+	link := c.driveURL("/items/" + url.PathEscape(folder.Id) + "/delta")
+	for link != "" {
+		page, err := c.GetDeltaPage(ctx, link)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page.Value {
+			if item.File != nil && item.Deleted == nil {
+				files = append(files, item)
+			}
+		}
+		link = page.NextLink
+	}
+
+	return files, nil
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingHandler counts the requests and responds to them with their
+// client-request-id header once release is closed.
+type blockingHandler struct {
+	requests atomic.Int32
+	started  chan struct{} // receives a value for every request
+	release  chan struct{}
+}
+
+func newBlockingHandler() *blockingHandler {
+	return &blockingHandler{started: make(chan struct{}, 100), release: make(chan struct{})}
+}
+
+func (h *blockingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.requests.Add(1)
+	h.started <- struct{}{}
+	<-h.release
+	w.Write([]byte(`{"id":"` + r.Header.Get("client-request-id") + `"}`))
+}
+
+// getAll calls c.get for url concurrently with each of ctxs, once the first
+// request has started, and returns the bodies and errors once release is closed.
+func getAll(c *OneDriveClient, h *blockingHandler, url string, ctxs []context.Context) ([]string, []error) {
+	bodies := make([]string, len(ctxs))
+	errs := make([]error, len(ctxs))
+
+	var wg sync.WaitGroup
+	for i, ctx := range ctxs {
+		wg.Add(1)
+		go func(i int, ctx context.Context) {
+			defer wg.Done()
+			body, err := c.get(ctx, url)
+			bodies[i], errs[i] = string(body), err
+		}(i, ctx)
+		if i == 0 {
+			<-h.started
+		}
+	}
+
+	// let the other callers join or start their requests
+	time.Sleep(50 * time.Millisecond)
+	close(h.release)
+	wg.Wait()
+
+	return bodies, errs
+}
+
+func TestGetShared(t *testing.T) {
+	h := newBlockingHandler()
+	c := newTestClient(t, h)
+
+	ctxs := make([]context.Context, 5)
+	for i := range ctxs {
+		ctxs[i] = WithRequestOptions(context.Background(), WithCorrelationID("same"))
+	}
+	bodies, errs := getAll(c, h, c.buildURL("/me/drive"), ctxs)
+
+	for i := range ctxs {
+		if errs[i] != nil || bodies[i] != `{"id":"same"}` {
+			t.Errorf("caller %d: body %q, err %v", i, bodies[i], errs[i])
+		}
+	}
+	if n := h.requests.Load(); n != 1 {
+		t.Errorf("%d requests, want 1", n)
+	}
+}
+
+func TestGetSharedRequestOptions(t *testing.T) {
+	h := newBlockingHandler()
+	c := newTestClient(t, h)
+
+	ctxs := []context.Context{
+		WithRequestOptions(context.Background(), WithCorrelationID("a")),
+		WithRequestOptions(context.Background(), WithCorrelationID("b")),
+		WithRequestOptions(context.Background(), WithCorrelationID("a")),
+	}
+	bodies, errs := getAll(c, h, c.buildURL("/me/drive"), ctxs)
+
+	for i, want := range []string{`{"id":"a"}`, `{"id":"b"}`, `{"id":"a"}`} {
+		if errs[i] != nil || bodies[i] != want {
+			t.Errorf("caller %d: body %q, err %v, want %s", i, bodies[i], errs[i], want)
+		}
+	}
+	if n := h.requests.Load(); n != 2 {
+		t.Errorf("%d requests, want 2, one for each correlation ID", n)
+	}
+}
+
+func TestGetSharedCancelled(t *testing.T) {
+	h := newBlockingHandler()
+	c := newTestClient(t, h)
+	url := c.buildURL("/me/drive")
+
+	type result struct {
+		body string
+		err  error
+	}
+	get := func(ctx context.Context) chan result {
+		ch := make(chan result, 1)
+		go func() {
+			body, err := c.get(ctx, url)
+			ch <- result{string(body), err}
+		}()
+		return ch
+	}
+
+	// the first caller, which starts the shared request, gives up while it is in flight
+	ctx, cancel := context.WithCancel(context.Background())
+	first := get(ctx)
+	<-h.started
+	other := get(context.Background())
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case res := <-first:
+		if !errors.Is(res.err, context.Canceled) {
+			t.Errorf("cancelled caller: err = %v, want context.Canceled", res.err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelled caller did not return")
+	}
+
+	// the request continues for the other caller
+	close(h.release)
+	res := <-other
+	if res.err != nil || res.body != `{"id":""}` {
+		t.Errorf("other caller: body %q, err %v", res.body, res.err)
+	}
+	if n := h.requests.Load(); n != 1 {
+		t.Errorf("%d requests, want 1", n)
+	}
+}
+
+func TestGetSharedWaiterCancelled(t *testing.T) {
+	h := newBlockingHandler()
+	c := newTestClient(t, h)
+	defer close(h.release)
+
+	go c.get(context.Background(), c.buildURL("/me/drive"))
+	<-h.started
+
+	// a caller that joins the request returns when its own context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := c.get(ctx, c.buildURL("/me/drive"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,328 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false,
+	"regenerate the golden files in testdata from the Graph API, requires ONEDRIVE_INTEGRATION_TEST=1")
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+
+	if *update {
+		if os.Getenv("ONEDRIVE_INTEGRATION_TEST") != "1" {
+			fmt.Fprintln(os.Stderr, "-update requires ONEDRIVE_INTEGRATION_TEST=1 and the ONEDRIVE_* variables of NewFromEnvironment")
+			os.Exit(2)
+		}
+		err := updateGolden()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "update golden files:", err)
+			os.Exit(1)
+		}
+	}
+
+	os.Exit(m.Run())
+}
+
+// goldenFiles are the files in testdata and the Graph requests they are captured from.
+// {file} is replaced by the ID of the first file in the root folder.
+var goldenFiles = []struct {
+	name string
+	path string
+}{
+	{"user.json", "/me"},
+	{"drive.json", "/me/drive"},
+	{"root.json", "/me/drive/root"},
+	{"children.json", "/me/drive/root/children?$top=3"},
+	{"file.json", "/me/drive/items/{file}"},
+	{"delta.json", "/me/drive/root/delta?$top=3"},
+	{"permissions.json", "/me/drive/items/{file}/permissions"},
+	{"subscriptions.json", "/subscriptions"},
+}
+
+// updateGolden captures the golden files from the Graph API with the client of
+// NewFromEnvironment and redacts them. Review the changes before committing them,
+// and update the expectations of TestGolden to the new values.
+func updateGolden() error {
+	c, err := NewFromEnvironment()
+	if err != nil {
+		return err
+	}
+
+	body, err := c.Get(c.buildURL("/me/drive/root/children"))
+	if err != nil {
+		return err
+	}
+	var children DriveItems
+	err = json.Unmarshal(body, &children)
+	if err != nil {
+		return err
+	}
+	fileID := ""
+	for _, item := range children.Value {
+		if item.File != nil {
+			fileID = item.Id
+			break
+		}
+	}
+	if fileID == "" {
+		return errors.New("the root folder has no file")
+	}
+
+	for _, golden := range goldenFiles {
+		path := strings.ReplaceAll(golden.path, "{file}", url.PathEscape(fileID))
+		body, err := c.Get(c.buildURL(path))
+		if err != nil {
+			return fmt.Errorf("%s: %w", golden.name, err)
+		}
+
+		var data interface{}
+		err = json.Unmarshal(body, &data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", golden.name, err)
+		}
+		redact(data, "")
+
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(data)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(filepath.Join("testdata", golden.name), buf.Bytes(), 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// redactedFields are the fields replaced in captured golden files, by name.
+var redactedFields = map[string]string{
+	"@microsoft.graph.downloadUrl": "https://example.invalid/download",
+	"email":                        "user@example.com",
+	"mail":                         "user@example.com",
+	"userPrincipalName":            "user@example.com",
+	"clientState":                  "redacted",
+}
+
+// redact replaces the personal data and credentials in data, decoded JSON,
+// which is the value of the field named key.
+func redact(data interface{}, key string) {
+	switch data := data.(type) {
+	case map[string]interface{}:
+		for k, v := range data {
+			if replacement, ok := redactedFields[k]; ok && v != nil {
+				data[k] = replacement
+				continue
+			}
+			if k == "displayName" && key == "user" {
+				data[k] = "Test User"
+				continue
+			}
+			redact(v, k)
+		}
+	case []interface{}:
+		for _, v := range data {
+			redact(v, key)
+		}
+	}
+}
+
+// readGolden decodes the golden file name in testdata into v.
+func readGolden(t *testing.T, name string, v interface{}) {
+	t.Helper()
+
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = json.Unmarshal(body, v)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+}
+
+func TestGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		v     interface{}
+		check func(t *testing.T, v interface{})
+	}{
+		{"user.json", &User{}, func(t *testing.T, v interface{}) {
+			user := v.(*User)
+			if user.Id != "1a2b3c4d5e6f7a8b" || user.DisplayName != "Test User" ||
+				user.GivenName != "Test" || user.Surname != "User" ||
+				user.UserPrincipalName != "user@example.com" {
+				t.Errorf("user = %+v", *user)
+			}
+		}},
+		{"drive.json", &Drive{}, func(t *testing.T, v interface{}) {
+			drive := v.(*Drive)
+			if drive.Id != "1a2b3c4d5e6f7a8b" || drive.DriveType != "personal" || drive.Name != "OneDrive" {
+				t.Errorf("drive = %+v", *drive)
+			}
+			if drive.LastModifiedDateTime != "2024-05-03T09:14:55Z" {
+				t.Errorf("LastModifiedDateTime = %q", drive.LastModifiedDateTime)
+			}
+			if drive.Owner == nil || drive.Owner.User == nil || drive.Owner.User.DisplayName != "Test User" {
+				t.Errorf("Owner = %+v", drive.Owner)
+			}
+			want := Quota{Total: 5368709120, Used: 20631040, Remaining: 5347029504, Deleted: 1048576, State: "normal"}
+			if drive.Quota == nil || *drive.Quota != want {
+				t.Errorf("Quota = %+v, want %+v", drive.Quota, want)
+			}
+		}},
+		{"root.json", &DriveItem{}, func(t *testing.T, v interface{}) {
+			item := v.(*DriveItem)
+			if item.Root == nil || item.Folder == nil || item.Folder.ChildCount != 3 || item.File != nil {
+				t.Errorf("root = %+v", *item)
+			}
+			if item.Id != "1A2B3C4D5E6F7A8B!101" || item.Size != 20631040 || item.ETag == "" {
+				t.Errorf("root = %+v", *item)
+			}
+			if item.ParentReference == nil || item.ParentReference.DriveId != "1a2b3c4d5e6f7a8b" {
+				t.Errorf("ParentReference = %+v", item.ParentReference)
+			}
+		}},
+		{"file.json", &DriveItem{}, func(t *testing.T, v interface{}) {
+			item := v.(*DriveItem)
+			if item.Name != "IMG_0001.jpg" || item.Size != 2457689 || item.Folder != nil {
+				t.Errorf("file = %+v", *item)
+			}
+			if item.File == nil || item.File.MimeType != "image/jpeg" || item.File.Hashes == nil ||
+				item.File.Hashes.QuickXorHash != "gSnzkVY9qnXWjvCRr7dSUn9OOTo=" ||
+				item.File.Hashes.Sha1Hash == "" || item.File.Hashes.Sha256Hash == "" {
+				t.Errorf("File = %+v", item.File)
+			}
+			want := ParentReference{DriveId: "1a2b3c4d5e6f7a8b", DriveType: "personal",
+				Id: "1A2B3C4D5E6F7A8B!105", Path: "/drive/root:/Pictures"}
+			if item.ParentReference == nil || *item.ParentReference != want {
+				t.Errorf("ParentReference = %+v, want %+v", item.ParentReference, want)
+			}
+			if item.FileSystemInfo.LastModifiedDateTime != "2023-07-14T15:58:02Z" {
+				t.Errorf("FileSystemInfo = %+v", item.FileSystemInfo)
+			}
+			if item.Image == nil || item.Image.Width != 4032 || item.Image.Height != 3024 {
+				t.Errorf("Image = %+v", item.Image)
+			}
+			if item.CreatedBy == nil || item.CreatedBy.Application == nil || item.CreatedBy.Application.DisplayName != "OneDrive" {
+				t.Errorf("CreatedBy = %+v", item.CreatedBy)
+			}
+			if item.DownloadURL != "https://example.invalid/download" {
+				t.Errorf("DownloadURL = %q", item.DownloadURL)
+			}
+		}},
+		{"children.json", &DriveItems{}, func(t *testing.T, v interface{}) {
+			items := v.(*DriveItems)
+			var names []string
+			for _, item := range items.Value {
+				names = append(names, item.Name)
+			}
+			if got := strings.Join(names, ","); got != "Documents,Pictures,notes.txt" {
+				t.Errorf("names = %s", got)
+			}
+			if len(items.Value) == 3 && (items.Value[0].Folder == nil || items.Value[0].Folder.ChildCount != 12 ||
+				items.Value[2].File == nil || items.Value[2].ParentReference.Path != "/drive/root:") {
+				t.Errorf("items = %+v", items.Value)
+			}
+			if !strings.Contains(items.NextLink, "$skiptoken=") {
+				t.Errorf("NextLink = %q", items.NextLink)
+			}
+		}},
+		{"delta.json", &DeltaItems{}, func(t *testing.T, v interface{}) {
+			items := v.(*DeltaItems)
+			if len(items.Value) != 3 {
+				t.Fatalf("%d items, want 3", len(items.Value))
+			}
+			if items.Value[0].Root == nil || items.Value[1].Deleted != nil {
+				t.Errorf("items = %+v", items.Value)
+			}
+			if deleted := items.Value[2].Deleted; deleted == nil || deleted.State != "deleted" {
+				t.Errorf("Deleted = %+v", deleted)
+			}
+			if items.NextLink != "" || !strings.Contains(items.DeltaLink, "token=") {
+				t.Errorf("NextLink = %q, DeltaLink = %q", items.NextLink, items.DeltaLink)
+			}
+		}},
+		{"permissions.json", &struct{ Value []Permission }{}, func(t *testing.T, v interface{}) {
+			permissions := v.(*struct{ Value []Permission }).Value
+			if len(permissions) != 2 {
+				t.Fatalf("%d permissions, want 2", len(permissions))
+			}
+			owner, link := permissions[0], permissions[1]
+			if len(owner.Roles) != 1 || owner.Roles[0] != "owner" || owner.Link != nil ||
+				owner.GrantedTo == nil || owner.GrantedTo.User.DisplayName != "Test User" {
+				t.Errorf("owner = %+v", owner)
+			}
+			if link.Link == nil || link.Link.Type != LinkView || link.Link.Scope != "anonymous" ||
+				link.Link.WebURL == "" || !link.HasPassword || link.ExpirationDateTime != "2024-06-02T00:00:00Z" {
+				t.Errorf("link = %+v", link)
+			}
+			if len(link.GrantedToIdentities) != 1 || link.GrantedToIdentities[0].User.DisplayName != "Other User" {
+				t.Errorf("GrantedToIdentities = %+v", link.GrantedToIdentities)
+			}
+		}},
+		{"subscriptions.json", &struct{ Value []Subscription }{}, func(t *testing.T, v interface{}) {
+			subscriptions := v.(*struct{ Value []Subscription }).Value
+			if len(subscriptions) != 1 {
+				t.Fatalf("%d subscriptions, want 1", len(subscriptions))
+			}
+			sub := subscriptions[0]
+			if sub.Id != "7f105c7d-2dc5-4530-97cd-4e7ae6534c07" || sub.Resource != "/me/drive/root" ||
+				sub.ChangeType != "updated" || sub.NotificationURL != "https://example.com/webhook" {
+				t.Errorf("subscription = %+v", sub)
+			}
+			if want := time.Date(2024, 5, 5, 11, 23, 0, 0, time.UTC); !sub.Expiration().Equal(want) {
+				t.Errorf("Expiration = %v, want %v", sub.Expiration(), want)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readGolden(t, tt.name, tt.v)
+			tt.check(t, tt.v)
+		})
+	}
+}
+
+func TestGoldenFilesExist(t *testing.T) {
+	for _, golden := range goldenFiles {
+		_, err := os.Stat(filepath.Join("testdata", golden.name))
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ErrInvalidRotation is returned by RotateImage for a rotation other than 90, 180, or 270 degrees.
+var ErrInvalidRotation = errors.New("rotation must be 90, 180, or 270 degrees")
+
+// ErrNotImageItem is returned by RotateImage for an item that is not an image.
+var ErrNotImageItem = errors.New("item is not an image")
+
+// RotateImage rotates the image identified by itemID clockwise by degrees,
+// which must be 90, 180, or 270, and returns the item with its new dimensions.
+// The item is retrieved first; ErrNotImageItem is returned without rotating if
+// it is not an image. The rotate action is not part of the published Graph reference.
+func (c *OneDriveClient) RotateImage(ctx context.Context, itemID string, degrees int) (driveItem DriveItem, err error) {
+	switch degrees {
+	case 90, 180, 270:
+	default:
+		return DriveItem{}, ErrInvalidRotation
+	}
+
+	item, err := c.GetItemByID(ctx, itemID)
+	if err != nil {
+		return DriveItem{}, err
+	}
+	if !item.IsImage() {
+		return DriveItem{}, ErrNotImageItem
+	}
+
+	link := c.driveURL("/items/" + url.PathEscape(itemID) + "/image/rotate?value=" + strconv.Itoa(degrees))
+	err = c.doJSON(ctx, http.MethodPost, link, nil, &driveItem)
+	if err != nil {
+		return DriveItem{}, err
+	}
+	if driveItem.Id == "" {
+		// no item in the response, so retrieve the rotated item
+		return c.GetItemByID(ctx, itemID)
+	}
+
+	return driveItem, nil
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// ErrNoHashAvailable is returned when an item has no hash to verify against.
+var ErrNoHashAvailable = errors.New("no hash available for item")
+
+// VerifyFileIntegrity reports whether the file at localPath has the same content
+// as the item identified by itemID, by comparing the quickXorHash of the item,
+// or if not available, its sha1Hash or sha256Hash, with the hash of the local file.
+// ErrNoHashAvailable is returned if the item has none of these hashes.
+func (c *OneDriveClient) VerifyFileIntegrity(ctx context.Context, itemID string, localPath string) (bool, error) {
+	item, err := c.GetItemByID(ctx, itemID)
+	if err != nil {
+		return false, err
+	}
+
+	return verifyHashes(item, localPath)
+}
+
+// verifyHashes compares the best available hash of item with the hash of the file at localPath.
+func verifyHashes(item DriveItem, localPath string) (bool, error) {
+	check, err := newHashCheck(item)
+	if err != nil {
+		return false, err
+	}
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(check, file)
+	if err != nil {
+		return false, err
+	}
+
+	return check.match(), nil
+}
+
+// hashCheck computes the best available hash of the content written to it
+// to compare with the hash of an item.
+type hashCheck struct {
+	hash.Hash
+	expected string
+	quickXor bool
+}
+
+// newHashCheck returns a hashCheck for item,
+// or ErrNoHashAvailable if the item has no supported hash.
+func newHashCheck(item DriveItem) (*hashCheck, error) {
+	if item.File == nil || item.File.Hashes == nil {
+		return nil, ErrNoHashAvailable
+	}
+	hashes := item.File.Hashes
+
+	switch {
+	case hashes.QuickXorHash != "":
+		return &hashCheck{Hash: newQuickXorHash(), expected: hashes.QuickXorHash, quickXor: true}, nil
+	case hashes.Sha1Hash != "":
+		return &hashCheck{Hash: sha1.New(), expected: hashes.Sha1Hash}, nil
+	case hashes.Sha256Hash != "":
+		return &hashCheck{Hash: sha256.New(), expected: hashes.Sha256Hash}, nil
+	}
+
+	return nil, ErrNoHashAvailable
+}
+
+// actual returns the hash of the content written so far, encoded like the expected hash.
+func (hc *hashCheck) actual() string {
+	sum := hc.Sum(nil)
+	if hc.quickXor {
+		return base64.StdEncoding.EncodeToString(sum)
+	}
+
+	// Graph returns hex encoded hashes in upper case
+	return strings.ToUpper(hex.EncodeToString(sum))
+}
+
+// match reports whether the content written so far has the expected hash.
+func (hc *hashCheck) match() bool {
+	if hc.quickXor {
+		return hc.actual() == hc.expected
+	}
+
+	return strings.EqualFold(hc.actual(), hc.expected)
+}
+
+// ErrIntegrityCheckFailed is matched by the IntegrityError returned when downloaded
+// content does not have the hash of the item, see WithVerifyOnDownload.
+var ErrIntegrityCheckFailed = errors.New("integrity check failed")
+
+// IntegrityError reports downloaded content that does not have the hash of the item.
+// It matches ErrIntegrityCheckFailed with errors.Is.
+type IntegrityError struct {
+	// The identifier of the downloaded item.
+	ItemID string
+
+	// The hash of the item.
+	Expected string
+
+	// The hash of the downloaded content.
+	Actual string
+}
+
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf("%v for item %s: expected hash %s, got %s",
+		ErrIntegrityCheckFailed, e.ItemID, e.Expected, e.Actual)
+}
+
+// Is reports whether target is ErrIntegrityCheckFailed.
+func (e *IntegrityError) Is(target error) bool {
+	return target == ErrIntegrityCheckFailed
+}
+
+// downloadVerified writes the content of the file identified by itemID to w, hashing the content
+// as it is written, and returns an IntegrityError if it does not have the hash of the item.
+// Files without a hash, such as OneNote notebooks, are written without verification.
+func (c *OneDriveClient) downloadVerified(ctx context.Context, itemID string, w io.Writer) error {
+	item, err := c.getItemUncached(ctx, itemID, "id,file")
+	if err != nil {
+		return err
+	}
+
+	return c.downloadWithHash(ctx, itemID, item, w)
+}
+
+// getItemUncached retrieves the fields of the item identified by itemID without the cache,
+// which may hold a previous version, e.g., with the hash of the previous content.
+func (c *OneDriveClient) getItemUncached(ctx context.Context, itemID string, fields string) (item DriveItem, err error) {
+	body, err := c.get(ctx, c.driveURL("/items/"+url.PathEscape(itemID)+"?$select="+fields))
+	if err != nil {
+		return DriveItem{}, err
+	}
+
+	err = c.unmarshal(body, &item)
+
+	return item, err
+}
+
+// downloadWithHash is downloadVerified with item, the file identified by itemID
+// retrieved with its File facet.
+func (c *OneDriveClient) downloadWithHash(ctx context.Context, itemID string, item DriveItem, w io.Writer) error {
+	contentURL := c.driveURL("/items/" + url.PathEscape(itemID) + "/content")
+	check, err := newHashCheck(item)
+	if errors.Is(err, ErrNoHashAvailable) {
+		return c.download(ctx, contentURL, w)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = c.download(ctx, contentURL, io.MultiWriter(w, check))
+	if err != nil {
+		return err
+	}
+
+	if !check.match() {
+		return &IntegrityError{ItemID: itemID, Expected: check.expected, Actual: check.actual()}
+	}
+
+	return nil
+}
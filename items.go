@@ -0,0 +1,309 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// MimeType returns the MIME type of the item from its File facet, or if not
+// available, from the extension of its Name, or application/octet-stream.
+func (item DriveItem) MimeType() string {
+	if item.File != nil && item.File.MimeType != "" {
+		return item.File.MimeType
+	}
+
+	if t := mime.TypeByExtension(path.Ext(item.Name)); t != "" {
+		// drop parameters such as charset
+		if mediaType, _, err := mime.ParseMediaType(t); err == nil {
+			return mediaType
+		}
+		return t
+	}
+
+	return "application/octet-stream"
+}
+
+// HasMimeType reports whether the MIME type of the item is mimeType, ignoring case.
+func (item DriveItem) HasMimeType(mimeType string) bool {
+	return strings.EqualFold(item.MimeType(), mimeType)
+}
+
+// IsImage reports whether the item is an image, according to its Image facet
+// or, if that is missing, its MIME type.
+func (item DriveItem) IsImage() bool {
+	return item.Image != nil || strings.HasPrefix(strings.ToLower(item.MimeType()), "image/")
+}
+
+// IsCheckedOut reports whether the item is checked out, according to its
+// Publication facet. Publication.CheckedOutBy identifies who checked it out.
+func (item DriveItem) IsCheckedOut() bool {
+	return item.Publication != nil && item.Publication.Level == "checkout"
+}
+
+// CreatedByDisplayName returns the display name of the user, or else the application
+// or device, that created the item, or an empty string if it is not known.
+func (item DriveItem) CreatedByDisplayName() string {
+	return item.CreatedBy.DisplayName()
+}
+
+// LastModifiedByDisplayName returns the display name of the user, or else the application
+// or device, that last modified the item, or an empty string if it is not known.
+func (item DriveItem) LastModifiedByDisplayName() string {
+	return item.LastModifiedBy.DisplayName()
+}
+
+// modifiedTime returns the time the item was last modified according to its FileSystemInfo,
+// the time set by the client that uploaded it, or else according to Graph.
+func (item DriveItem) modifiedTime() (time.Time, error) {
+	modified := item.FileSystemInfo.LastModifiedDateTime
+	if modified == "" {
+		modified = item.LastModifiedDateTime
+	}
+
+	return time.Parse(time.RFC3339, modified)
+}
+
+// DisplayName returns the display name of the user, or else the application or device,
+// of the identity set, or an empty string if none has one. A nil IdentitySet returns "".
+func (s *IdentitySet) DisplayName() string {
+	if s == nil {
+		return ""
+	}
+
+	for _, identity := range []*Identity{s.User, s.Application, s.Device} {
+		if identity != nil && identity.DisplayName != "" {
+			return identity.DisplayName
+		}
+	}
+
+	return ""
+}
+
+// downloadURLLifetime is how long a DownloadURL is assumed to be valid after it was fetched.
+const downloadURLLifetime = 5 * time.Minute
+
+var (
+	// ErrNoDownloadURL is returned by ContentURL for an item without a download URL,
+	// e.g., a folder or an item retrieved without the @microsoft.graph.downloadUrl property.
+	ErrNoDownloadURL = errors.New("item has no download URL")
+
+	// ErrDownloadURLExpired is returned by ContentURL when the download URL of
+	// an item may have expired, so the item must be retrieved again.
+	ErrDownloadURLExpired = errors.New("download URL expired")
+)
+
+// UnmarshalJSON decodes the item and records when it was fetched, so ContentURL
+// can detect an expired download URL.
+func (item *DriveItem) UnmarshalJSON(b []byte) error {
+	type driveItem DriveItem // without the UnmarshalJSON method
+
+	err := json.Unmarshal(b, (*driveItem)(item))
+	if err != nil {
+		return err
+	}
+	if item.DownloadURL != "" {
+		item.fetched = time.Now()
+	}
+
+	return nil
+}
+
+// ContentURL returns the URL to download the content of the file without authentication.
+// Download URLs expire shortly, so ErrDownloadURLExpired is returned when the item was
+// fetched more than 5 minutes ago. The age of an item that was not decoded from JSON
+// is unknown, so its download URL is returned as is.
+func (item DriveItem) ContentURL() (string, error) {
+	if item.DownloadURL == "" {
+		return "", ErrNoDownloadURL
+	}
+	if !item.fetched.IsZero() && time.Since(item.fetched) > downloadURLLifetime {
+		return "", ErrDownloadURLExpired
+	}
+
+	return item.DownloadURL, nil
+}
+
+// ErrPathTraversal is returned when an item path would escape the local base directory.
+var ErrPathTraversal = errors.New("path escapes base directory")
+
+// IsSharePointItem reports whether item is stored in SharePoint,
+// such as in a document library or OneDrive for Business, see SharepointIds.
+func (item DriveItem) IsSharePointItem() bool {
+	return item.SharepointIds != nil
+}
+
+// WebDavPath returns the server-relative path of the item for WebDAV access to
+// OneDrive for Business and SharePoint, e.g., /sites/team/Shared Documents/report.docx,
+// which is the unescaped path of WebURL. An empty string is returned if the path cannot
+// be derived: WebURL is not a direct link, such as a /_layouts/ link to an Office document,
+// or is not below SharepointIds.SiteUrl when that is known. No network I/O is done.
+// WebDAV requires signing in with the Microsoft account of the user; the OAuth token of
+// the client cannot be used.
+func (item DriveItem) WebDavPath() string {
+	u, err := url.Parse(item.WebURL)
+	if err != nil || u.Host == "" || strings.Contains(u.Path, "/_layouts/") {
+		return ""
+	}
+
+	if item.SharepointIds != nil && item.SharepointIds.SiteUrl != "" {
+		site, err := url.Parse(item.SharepointIds.SiteUrl)
+		if err != nil || !strings.EqualFold(site.Host, u.Host) {
+			return ""
+		}
+		sitePath := strings.TrimSuffix(site.Path, "/")
+		if !strings.HasPrefix(strings.ToLower(u.Path), strings.ToLower(sitePath)+"/") {
+			return ""
+		}
+	}
+
+	return u.Path
+}
+
+// Path returns the path of item relative to the root of its drive,
+// e.g., /Documents/report.docx, or / for the root folder. The drive prefix of
+// ParentReference.Path, such as /drive/root: or /drives/{drive-id}/root:, is removed.
+func (item DriveItem) Path() string {
+	return path.Clean("/" + item.rawPath())
+}
+
+// rawPath is Path without cleaning, so components such as .. can still be detected.
+func (item DriveItem) rawPath() string {
+	if item.Root != nil {
+		return "/"
+	}
+
+	parent := ""
+	if item.ParentReference != nil {
+		parent = item.ParentReference.Path
+		if i := strings.Index(parent, ":"); i >= 0 {
+			parent = parent[i+1:]
+		}
+	}
+
+	return parent + "/" + item.Name
+}
+
+// BuildLocalPath returns the local path of item below baseDir.
+// If driveRootPath is empty, the path of item relative to the root of its drive,
+// see Path, is joined with baseDir using the OS path separator. Otherwise the remote
+// path of item, ParentReference.Path followed by Name, must start with driveRootPath,
+// e.g., /drive/root:/Documents, which is stripped before the rest of the remote path
+// is joined with baseDir.
+// Components such as .. that could escape baseDir return an error wrapping ErrPathTraversal.
+func BuildLocalPath(baseDir string, item DriveItem, driveRootPath string) (string, error) {
+	if item.ParentReference == nil {
+		return "", fmt.Errorf("item %s has no parent reference", item.Id)
+	}
+
+	relPath := item.rawPath()
+	if driveRootPath != "" {
+		parentPath := item.ParentReference.Path
+		if !strings.HasPrefix(parentPath, driveRootPath) {
+			return "", fmt.Errorf("path %q of item %s is not below %q",
+				parentPath, item.Id, driveRootPath)
+		}
+		relPath = strings.TrimPrefix(parentPath, driveRootPath) + "/" + item.Name
+	}
+
+	parts := []string{baseDir}
+	for _, part := range strings.Split(relPath, "/") {
+		if part == "" {
+			continue
+		}
+		if part == "." || part == ".." || strings.ContainsRune(part, filepath.Separator) {
+			return "", fmt.Errorf("%w: %q", ErrPathTraversal, relPath)
+		}
+		parts = append(parts, part)
+	}
+	localPath := filepath.Join(parts...)
+
+	// double check that the result is below baseDir
+	rel, err := filepath.Rel(baseDir, localPath)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %q", ErrPathTraversal, relPath)
+	}
+
+	return localPath, nil
+}
+
+// ErrCyclicReference is returned when items refer to each other as parents.
+var ErrCyclicReference = errors.New("cyclic parent reference")
+
+// FlattenDriveItems sorts items so that a parent always appears before its children,
+// using the Id of each ParentReference. Otherwise, the original order is kept
+// as far as possible. A cycle of parent references returns an error wrapping
+// ErrCyclicReference.
+func FlattenDriveItems(items []DriveItem) ([]DriveItem, error) {
+	// index of each item by id
+	index := make(map[string]int, len(items))
+	for i, item := range items {
+		index[item.Id] = i
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(items))
+	sorted := make([]DriveItem, 0, len(items))
+
+	// visit appends the ancestors of item i and then item i
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("%w: item %s", ErrCyclicReference, items[i].Id)
+		}
+		state[i] = visiting
+
+		if ref := items[i].ParentReference; ref != nil {
+			if p, ok := index[ref.Id]; ok {
+				err := visit(p)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		state[i] = visited
+		sorted = append(sorted, items[i])
+
+		return nil
+	}
+
+	for i := range items {
+		err := visit(i)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
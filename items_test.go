@@ -0,0 +1,60 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import "testing"
+
+func TestWebDavPath(t *testing.T) {
+	const site = "https://contoso.sharepoint.com/sites/team"
+
+	tests := []struct {
+		name    string
+		webURL  string
+		siteURL string
+		want    string
+	}{
+		{"document library", "https://contoso.sharepoint.com/sites/team/Shared%20Documents/report.docx", site,
+			"/sites/team/Shared Documents/report.docx"},
+		{"folder", "https://contoso.sharepoint.com/sites/team/Shared%20Documents/Q1%20Plans", site + "/",
+			"/sites/team/Shared Documents/Q1 Plans"},
+		{"OneDrive for Business", "https://contoso-my.sharepoint.com/personal/user_contoso_com/Documents/notes.txt", "",
+			"/personal/user_contoso_com/Documents/notes.txt"},
+		{"site in other case", "https://contoso.sharepoint.com/Sites/Team/Shared%20Documents/a.txt", site,
+			"/Sites/Team/Shared Documents/a.txt"},
+		{"non-ASCII name", "https://contoso.sharepoint.com/sites/team/Shared%20Documents/%C3%BCbersicht.xlsx", site,
+			"/sites/team/Shared Documents/übersicht.xlsx"},
+		{"Office link", "https://contoso.sharepoint.com/sites/team/_layouts/15/Doc.aspx?sourcedoc=%7B1234%7D&file=report.docx", site, ""},
+		{"other host", "https://fabrikam.sharepoint.com/sites/team/Shared%20Documents/a.txt", site, ""},
+		{"other site", "https://contoso.sharepoint.com/sites/other/Shared%20Documents/a.txt", site, ""},
+		{"site prefix of another site", "https://contoso.sharepoint.com/sites/teamwork/Shared%20Documents/a.txt", site, ""},
+		{"relative", "/sites/team/Shared%20Documents/a.txt", "", ""},
+		{"invalid", "https://contoso.sharepoint.com/sites/%zz", "", ""},
+		{"empty", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		item := DriveItem{WebURL: tt.webURL}
+		if tt.siteURL != "" {
+			item.SharepointIds = &SharepointIds{SiteUrl: tt.siteURL}
+		}
+
+		if got := item.WebDavPath(); got != tt.want {
+			t.Errorf("%s: WebDavPath(%q) = %q, want %q", tt.name, tt.webURL, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// ListItem is an item in a SharePoint list, such as the list item of a file in a
+// document library, which carries the metadata columns of the library in Fields.
+type ListItem struct {
+	// The unique identifier of the item within the list. Read-only.
+	Id string `json:"id,omitempty"`
+
+	// URL that displays the item in the browser. Read-only.
+	WebURL string `json:"webUrl,omitempty"`
+
+	// The values of the columns of the item, by column name.
+	Fields map[string]interface{} `json:"fields,omitempty"`
+
+	// Identity of the user, device, and application which created the item. Read-only.
+	CreatedBy *IdentitySet `json:"createdBy,omitempty"`
+
+	// Date and time of item creation. Read-only.
+	CreatedDateTime string `json:"createdDateTime,omitempty"`
+
+	// Identity of the user, device, and application which last modified the item. Read-only.
+	LastModifiedBy *IdentitySet `json:"lastModifiedBy,omitempty"`
+
+	// Date and time the item was last modified. Read-only.
+	LastModifiedDateTime string `json:"lastModifiedDateTime,omitempty"`
+}
+
+// GetSharePointListItem retrieves the item identified by listItemID, including its fields,
+// in the list identified by listID of the site identified by siteID.
+// The IDs of a DriveItem in a document library are in its SharepointIds.
+func (c *OneDriveClient) GetSharePointListItem(ctx context.Context, siteID, listID, listItemID string) (listItem ListItem, err error) {
+	body, err := c.get(ctx, c.listItemURL(siteID, listID, listItemID)+"?$expand=fields")
+	if err != nil {
+		return ListItem{}, err
+	}
+
+	err = c.unmarshal(body, &listItem)
+
+	return listItem, err
+}
+
+// UpdateSharePointListItem sets the columns of the list item identified by listItemID
+// to the values in fields and returns the item with the updated fields.
+// Graph only returns the fields, so only Id and Fields of the returned item are set.
+func (c *OneDriveClient) UpdateSharePointListItem(ctx context.Context, siteID, listID, listItemID string, fields map[string]interface{}) (listItem ListItem, err error) {
+	var updated map[string]interface{}
+	err = c.doJSON(ctx, http.MethodPatch, c.listItemURL(siteID, listID, listItemID)+"/fields", fields, &updated)
+	if err != nil {
+		return ListItem{}, err
+	}
+
+	return ListItem{Id: listItemID, Fields: updated}, nil
+}
+
+// GetItemBySharepointID retrieves the DriveItem of the list item identified by
+// listItemID in the list identified by listID of the site identified by siteID,
+// such as the IDs of a file obtained from the SharePoint REST API.
+// listItemID is the ListItemId of SharepointIds.
+func (c *OneDriveClient) GetItemBySharepointID(ctx context.Context, siteID, listID, listItemID string) (driveItem DriveItem, err error) {
+	body, err := c.get(ctx, c.listItemURL(siteID, listID, listItemID)+"/driveItem")
+	if err != nil {
+		return DriveItem{}, err
+	}
+
+	err = c.unmarshal(body, &driveItem)
+
+	return driveItem, err
+}
+
+// listItemURL returns the URL of the list item identified by listItemID.
+func (c *OneDriveClient) listItemURL(siteID, listID, listItemID string) string {
+	return c.buildURL("/sites/" + url.PathEscape(siteID) + "/lists/" + url.PathEscape(listID) +
+		"/items/" + url.PathEscape(listItemID))
+}
@@ -0,0 +1,28 @@
+//go:build !unix
+
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import "time"
+
+// lockFile does nothing, since flock is not available on this platform,
+// e.g., on Windows. Processes sharing a token file are not coordinated.
+func lockFile(fileName string, timeout time.Duration) (unlock func(), err error) {
+	return func() {}, nil
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ConflictBehavior is what Graph does when an item is created or moved to a folder
+// that already has an item with the same name.
+type ConflictBehavior string
+
+const (
+	// ConflictFail fails the request, leaving both items unchanged.
+	ConflictFail ConflictBehavior = "fail"
+
+	// ConflictReplace replaces the existing item, including all of its content for a folder.
+	ConflictReplace ConflictBehavior = "replace"
+
+	// ConflictRename keeps both items by giving the new item a unique name.
+	ConflictRename ConflictBehavior = "rename"
+)
+
+// MergeReport describes the results of MergeFolder.
+type MergeReport struct {
+	// Number of items moved to the destination folder.
+	Moved int
+
+	// Number of items not moved because of a conflict with ConflictFail.
+	Skipped int
+
+	// Number of items with the same name as an item in the destination folder,
+	// whether skipped, replaced, or renamed.
+	Conflicted int
+
+	// Number of items that could not be moved for other reasons, see Errors.
+	Failed int
+
+	// The error of each failed item, prefixed by the name of the item.
+	Errors []error
+}
+
+// MergeFolder moves all children of the folder identified by srcFolderID to the folder
+// identified by dstFolderID. Children with the same name as an item in the destination,
+// compared case-insensitively like OneDrive, are handled according to conflictPolicy;
+// ConflictReplace replaces a folder in the destination with the folder from the source
+// instead of merging their contents. If deleteSource is true and every child was moved,
+// the source folder is deleted. Errors moving individual items are reported in the
+// MergeReport; the error is only set if the folders cannot be listed or the source deleted.
+func (c *OneDriveClient) MergeFolder(ctx context.Context, srcFolderID, dstFolderID string, conflictPolicy ConflictBehavior, deleteSource bool) (report MergeReport, err error) {
+	children, err := c.listAllChildren(ctx, srcFolderID)
+	if err != nil {
+		return MergeReport{}, err
+	}
+
+	existing, err := c.listAllChildren(ctx, dstFolderID)
+	if err != nil {
+		return MergeReport{}, err
+	}
+	names := make(map[string]bool, len(existing))
+	for _, item := range existing {
+		names[strings.ToLower(item.Name)] = true
+	}
+
+	for _, child := range children {
+		conflict := names[strings.ToLower(child.Name)]
+		if conflict {
+			report.Conflicted++
+			if conflictPolicy == ConflictFail {
+				report.Skipped++
+				continue
+			}
+		}
+
+		_, err := c.moveItem(ctx, child.Id, dstFolderID, conflictPolicy)
+		switch {
+		case err == nil:
+			report.Moved++
+		case isNameConflict(err) && conflictPolicy == ConflictFail:
+			// created in the destination after it was listed
+			report.Conflicted++
+			report.Skipped++
+		default:
+			report.Failed++
+			report.Errors = append(report.Errors, fmt.Errorf("%s: %w", child.Name, err))
+		}
+
+		if ctx.Err() != nil {
+			return report, ctx.Err()
+		}
+	}
+
+	if deleteSource && report.Moved == len(children) {
+		err = c.DeleteItem(ctx, srcFolderID)
+	}
+
+	return report, err
+}
+
+// moveItem moves the item identified by itemID into the folder identified by dstParentID,
+// resolving a name conflict according to behavior.
+func (c *OneDriveClient) moveItem(ctx context.Context, itemID, dstParentID string, behavior ConflictBehavior) (driveItem DriveItem, err error) {
+	link := c.driveURL("/items/" + url.PathEscape(itemID))
+	if behavior != "" {
+		link += "?@microsoft.graph.conflictBehavior=" + url.QueryEscape(string(behavior))
+	}
+
+	in := copyMove{ParentReference: itemReference{Id: dstParentID}}
+	err = c.doJSON(ctx, http.MethodPatch, link, in, &driveItem)
+
+	return driveItem, err
+}
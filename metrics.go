@@ -0,0 +1,114 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Metrics records the Graph API calls made by a OneDriveClient.
+// The endpoint is the route of the request, the path of the request URL with
+// identifiers and item paths replaced by placeholders, e.g., /v1.0/me/drive/items/{id}/children,
+// so it has a bounded number of values and can be used as a metric label.
+// Requests to pre-authenticated URLs, such as upload URLs, have the endpoint {preauthenticated}.
+// Implementations must be safe to call from multiple goroutines.
+type Metrics interface {
+	// RecordCall records a call that received a response with statusCode after latency.
+	RecordCall(method, endpoint string, statusCode int, latency time.Duration)
+
+	// RecordError records a failed call. errCode is the Graph error code,
+	// or transportError if no response was received.
+	RecordError(method, endpoint, errCode string)
+}
+
+// NoopMetrics discards all metrics. It is the default Metrics of a client.
+type NoopMetrics struct{}
+
+// RecordCall does nothing.
+func (NoopMetrics) RecordCall(method, endpoint string, statusCode int, latency time.Duration) {}
+
+// RecordError does nothing.
+func (NoopMetrics) RecordError(method, endpoint, errCode string) {}
+
+// routeSegments are the literal path segments of Graph routes; any other segment is an identifier.
+var routeSegments = map[string]bool{
+	"v1.0": true, "beta": true, "$batch": true,
+	"me": true, "users": true, "groups": true, "sites": true, "shares": true, "subscriptions": true,
+	"drive": true, "drives": true, "items": true, "root": true, "special": true, "recent": true,
+	"sharedWithMe": true, "search": true, "query": true, "driveItem": true, "listItem": true,
+	"lists": true, "fields": true, "children": true, "content": true, "delta": true,
+	"permissions": true, "invite": true, "createLink": true, "revokeGrants": true,
+	"copy": true, "checkin": true, "checkout": true, "permanentDelete": true, "restore": true,
+	"preview": true, "thumbnails": true, "versions": true, "comments": true, "activities": true,
+	"createUploadSession": true, "image": true, "rotate": true, "follow": true, "unfollow": true,
+}
+
+// endpoint returns the endpoint of u for Metrics.
+func (c *OneDriveClient) endpoint(u *url.URL) string {
+	graph, err := url.Parse(c.host())
+	if err != nil || !strings.EqualFold(u.Host, graph.Host) {
+		return "{preauthenticated}"
+	}
+
+	return routeTemplate(u.Path)
+}
+
+// routeTemplate returns path with identifiers replaced by {id}, item paths addressed
+// with colons, e.g., /root:/a/b.txt:, by {path}, and function parameters, e.g.,
+// delta(token='...'), by an ellipsis.
+func routeTemplate(path string) string {
+	var (
+		route  []string
+		inPath bool // within an item path
+		names  int  // segments of the item path
+	)
+
+	for _, seg := range strings.Split(strings.Trim(path, "/"), "/") {
+		if inPath {
+			names++
+			if strings.HasSuffix(seg, ":") {
+				route = append(route, "{path}:")
+				inPath = false
+			}
+			continue
+		}
+
+		name, colon := strings.CutSuffix(seg, ":")
+		params := ""
+		if i := strings.IndexByte(name, '('); i >= 0 {
+			name, params = name[:i], "(...)"
+		}
+		if !routeSegments[name] {
+			name, params = "{id}", ""
+		}
+
+		if colon {
+			route = append(route, name+params+":")
+			inPath, names = true, 0
+		} else {
+			route = append(route, name+params)
+		}
+	}
+	if inPath && names > 0 {
+		route = append(route, "{path}")
+	}
+
+	return "/" + strings.Join(route, "/")
+}
@@ -0,0 +1,73 @@
+//go:build prometheus
+
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PrometheusMetrics records metrics with Prometheus collectors.
+// It is only available when building with the prometheus build tag.
+type PrometheusMetrics struct {
+	calls    *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+	failures *prometheus.CounterVec
+}
+
+// NewPrometheusMetrics creates PrometheusMetrics and registers its collectors with reg.
+func NewPrometheusMetrics(reg prometheus.Registerer) (*PrometheusMetrics, error) {
+	m := &PrometheusMetrics{
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "onedrive_calls_total",
+			Help: "Number of Graph API calls by method, endpoint, and status code.",
+		}, []string{"method", "endpoint", "code"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "onedrive_call_duration_seconds",
+			Help: "Latency of Graph API calls by method and endpoint.",
+		}, []string{"method", "endpoint"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "onedrive_errors_total",
+			Help: "Number of failed Graph API calls by method, endpoint, and error code.",
+		}, []string{"method", "endpoint", "error"}),
+	}
+
+	for _, c := range []prometheus.Collector{m.calls, m.latency, m.failures} {
+		err := reg.Register(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// RecordCall counts the call and observes its latency.
+func (m *PrometheusMetrics) RecordCall(method, endpoint string, statusCode int, latency time.Duration) {
+	m.calls.WithLabelValues(method, endpoint, strconv.Itoa(statusCode)).Inc()
+	m.latency.WithLabelValues(method, endpoint).Observe(latency.Seconds())
+}
+
+// RecordError counts the failed call.
+func (m *PrometheusMetrics) RecordError(method, endpoint, errCode string) {
+	m.failures.WithLabelValues(method, endpoint, errCode).Inc()
+}
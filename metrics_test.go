@@ -0,0 +1,108 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRouteTemplate(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/v1.0/me/drive", "/v1.0/me/drive"},
+		{"/v1.0/me/drive/items/01BYE5RZ6QN3ZWBTUFOFD3GSPGOHDJD36K", "/v1.0/me/drive/items/{id}"},
+		{"/v1.0/drives/b!t18F8ybsHUq1z3LTz8xvZqP8zaSWjkFNhsME-Fepo75dTf9vQKfeRblBZjoSQrd7/items/01A/children",
+			"/v1.0/drives/{id}/items/{id}/children"},
+		{"/v1.0/me/drive/root:/Documents/report.docx:/content", "/v1.0/me/drive/root:/{path}:/content"},
+		{"/v1.0/me/drive/root:/Documents/report.docx", "/v1.0/me/drive/root:/{path}"},
+		{"/v1.0/me/drive/root:/a.txt:", "/v1.0/me/drive/root:/{path}:"},
+		{"/v1.0/me/drive/items/01A:/new.txt:/content", "/v1.0/me/drive/items/{id}:/{path}:/content"},
+		{"/v1.0/me/drive/root/delta(token='abc')", "/v1.0/me/drive/root/delta(...)"},
+		{"/v1.0/me/drive/special/documents", "/v1.0/me/drive/special/{id}"},
+		{"/v1.0/shares/u!aHR0cHM6Ly9leGFtcGxl/driveItem", "/v1.0/shares/{id}/driveItem"},
+		{"/v1.0/sites/contoso.sharepoint.com,1,2/lists/L/items/7/fields", "/v1.0/sites/{id}/lists/{id}/items/{id}/fields"},
+		{"/beta/me/drive/activities", "/beta/me/drive/activities"},
+		{"/v1.0/$batch", "/v1.0/$batch"},
+	}
+
+	for _, tt := range tests {
+		if got := routeTemplate(tt.path); got != tt.want {
+			t.Errorf("routeTemplate(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+// recordingMetrics records the endpoints of the calls.
+type recordingMetrics struct {
+	mu        sync.Mutex
+	endpoints []string
+}
+
+func (m *recordingMetrics) RecordCall(method, endpoint string, statusCode int, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.endpoints = append(m.endpoints, endpoint)
+}
+
+func (m *recordingMetrics) RecordError(method, endpoint, errCode string) {}
+
+func TestMetricsEndpoint(t *testing.T) {
+	metrics := &recordingMetrics{}
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"1"}`))
+	}), WithMetrics(metrics))
+
+	_, err := c.GetItemByID(context.Background(), "01BYE5RZ6QN3ZWBTUFOFD3GSPGOHDJD36K")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.GetItemByPath(context.Background(), "/Documents/report.docx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// pre-authenticated URLs are on other hosts
+	pre := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"completed"}`))
+	}))
+	req, err := http.NewRequest(http.MethodGet, pre.host()+"/monitor/1234", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.sendVia(c.uploadClient, req, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"/v1.0/me/drive/items/{id}", "/v1.0/me/drive/root:/{path}:", "{preauthenticated}"}
+	if len(metrics.endpoints) != len(want) {
+		t.Fatalf("endpoints = %q, want %q", metrics.endpoints, want)
+	}
+	for i := range want {
+		if metrics.endpoints[i] != want[i] {
+			t.Errorf("endpoints[%d] = %q, want %q", i, metrics.endpoints[i], want[i])
+		}
+	}
+}
@@ -21,17 +21,23 @@ package onedrive
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"golang.org/x/oauth2"
+	"golang.org/x/sync/singleflight"
 )
 
 func init() {
@@ -40,146 +46,580 @@ func init() {
 }
 
 func (c *OneDriveClient) Get(url string) (body []byte, err error) {
-	resp, err := c.httpClient.Get(url)
+	return c.get(context.Background(), url)
+}
+
+// sharedGetTimeout bounds a shared GET request, which is not cancelled with the
+// contexts of its callers, if the client has no timeout.
+const sharedGetTimeout = 5 * time.Minute
+
+// get performs a GET request for url using ctx and returns the response body.
+// Concurrent GET requests for the same url and headers share a single request,
+// which runs until it completes or times out even if its callers are cancelled,
+// while each caller returns when its own ctx is done.
+func (c *OneDriveClient) get(ctx context.Context, url string) (body []byte, err error) {
+	if c.inflight == nil {
+		return c.do(ctx, http.MethodGet, url, nil)
+	}
+
+	key, err := inflightKey(ctx, url)
 	if err != nil {
-		return body, err
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err = ioutil.ReadAll(resp.Body)
+	ch := c.inflight.DoChan(key, func() (interface{}, error) {
+		timeout := c.httpClient.Timeout
+		if timeout <= 0 {
+			timeout = sharedGetTimeout
+		}
+		// keep the request options and other values of ctx, but not its cancellation
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+		defer cancel()
+
+		return c.do(ctx, http.MethodGet, url, nil)
+	})
+
+	select {
+	case res := <-ch:
+		body, _ = res.Val.([]byte)
+		if res.Shared {
+			// each caller gets its own copy of the body
+			body = append([]byte(nil), body...)
+		}
+		return body, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// inflightKey returns the key that identifies a GET request for url with the
+// request options carried by ctx, which may change its URL and headers.
+func inflightKey(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	applyRequestOptions(req)
+
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var key strings.Builder
+	key.WriteString(req.URL.String())
+	for _, name := range names {
+		for _, value := range req.Header[name] {
+			fmt.Fprintf(&key, "\n%s: %s", name, value)
+		}
+	}
+
+	return key.String(), nil
+}
+
+// do performs an HTTP request using ctx and returns the response body.
+// A Graph error response is returned as a *RespError.
+func (c *OneDriveClient) do(ctx context.Context, method, url string, reqBody io.Reader) (body []byte, err error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
 	if err != nil {
 		return body, err
 	}
 
-	if codeIsError(resp.StatusCode) {
-		resError := RespError{}
+	_, body, err = c.send(req)
+
+	return body, err
+}
 
-		err = json.Unmarshal(body, &resError)
+// doJSON performs an HTTP request using ctx with in, if not nil, encoded as the
+// JSON request body, and decodes a non-empty JSON response body into out, if not nil.
+func (c *OneDriveClient) doJSON(ctx context.Context, method, url string, in, out interface{}) error {
+	var reqBody io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	_, body, err := c.send(req)
+	if err != nil {
+		return err
+	}
+
+	if out == nil || len(body) == 0 {
+		return nil
+	}
+
+	return c.unmarshal(body, out)
+}
+
+// send sends req and returns the response, with the body already read and closed,
+// and the response body. A Graph error response is returned as a *RespError.
+func (c *OneDriveClient) send(req *http.Request) (resp *http.Response, body []byte, err error) {
+	var buf bytes.Buffer
+
+	resp, err = c.sendTo(req, &buf)
+	if err != nil {
+		return resp, nil, err
+	}
+
+	return resp, buf.Bytes(), nil
+}
+
+// sendTo sends req, copies the body of a successful response to w, and returns
+// the response with the body closed. A Graph error response is returned as a *RespError.
+func (c *OneDriveClient) sendTo(req *http.Request, w io.Writer) (resp *http.Response, err error) {
+	if c.anchorMailbox != "" {
+		req.Header.Set("X-AnchorMailbox", c.anchorMailbox)
+	}
+
+	return c.sendVia(c.httpClient, req, w)
+}
+
+// streamTo is sendTo for requests that transfer file content, which are not limited
+// by the timeout of the client, only by the context and the response header timeout.
+func (c *OneDriveClient) streamTo(req *http.Request, w io.Writer) (resp *http.Response, err error) {
+	if c.anchorMailbox != "" {
+		req.Header.Set("X-AnchorMailbox", c.anchorMailbox)
+	}
+
+	return c.sendVia(c.streamClient, req, w)
+}
+
+// sendVia is sendTo using httpClient to send req.
+func (c *OneDriveClient) sendVia(httpClient *http.Client, req *http.Request, w io.Writer) (resp *http.Response, err error) {
+	method := req.Method
+	endpoint := c.endpoint(req.URL)
+
+	if c.requestID != "" {
+		req.Header.Set("client-request-id", c.requestID)
+	}
+	applyRequestOptions(req)
+
+	for _, hook := range c.beforeRequest {
+		err = hook(req)
 		if err != nil {
 			return nil, err
 		}
+	}
 
-		return nil, &resError
+	if c.breaker != nil {
+		err = c.breaker.allow()
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return body, err
+	if c.cache != nil && method != http.MethodGet && method != http.MethodHead {
+		// once the write is done, cached items may be stale
+		defer c.cache.invalidate()
+	}
+
+	start := time.Now()
+	resp, err = httpClient.Do(req)
+	for _, hook := range c.afterResponse {
+		hook(req, resp, err)
+	}
+	if err != nil {
+		c.metrics.RecordError(method, endpoint, "transportError")
+		if c.breaker != nil {
+			c.breaker.recordResult(req.Context(), 0, err)
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+	c.metrics.RecordCall(method, endpoint, resp.StatusCode, time.Since(start))
+
+	if codeIsError(resp.StatusCode) {
+		body, err := ioutil.ReadAll(resp.Body)
+		if c.breaker != nil {
+			c.breaker.recordResult(req.Context(), resp.StatusCode, err)
+		}
+		if err != nil {
+			return resp, err
+		}
+
+		resError, err := parseRespError(body, resp.StatusCode, resp.Header.Get("client-request-id"))
+		if err != nil {
+			return resp, err
+		}
+
+		errCode := ""
+		if resError.Err != nil {
+			errCode = resError.Err.Code
+		}
+		c.metrics.RecordError(method, endpoint, errCode)
+
+		return resp, resError
+	}
+
+	_, err = io.Copy(w, resp.Body)
+	if c.breaker != nil {
+		c.breaker.recordResult(req.Context(), resp.StatusCode, err)
+	}
+
+	return resp, err
 }
 
 func (c *OneDriveClient) GetMyDrive() (drive Drive, err error) {
-	body, err := c.Get("https://graph.microsoft.com/v1.0/me/drive")
+	body, err := c.Get(c.buildURL("/me/drive"))
 	if err != nil {
 		return Drive{}, err
 	}
 
-	err = json.Unmarshal(body, &drive)
+	err = c.unmarshal(body, &drive)
 
 	return drive, err
 }
 
 // ListMyDrives retrieve a list of Drives available for the current user
 func (c *OneDriveClient) ListMyDrives() (drives Drives, err error) {
-	body, err := c.Get("https://graph.microsoft.com/v1.0/me/drives")
+	body, err := c.Get(c.buildURL("/me/drives"))
 	if err != nil {
 		return Drives{}, err
 	}
 
-	err = json.Unmarshal(body, &drives)
+	err = c.unmarshal(body, &drives)
 
 	return drives, err
 }
 
+// GetMyProfile retrieves the profile of the current user.
+// It can also be used to check that the token is still valid,
+// since a RespError with code InvalidAuthenticationToken is returned
+// when the token has expired and cannot be refreshed.
+func (c *OneDriveClient) GetMyProfile(ctx context.Context) (user User, err error) {
+	body, err := c.get(ctx, c.buildURL("/me"))
+	if err != nil {
+		return User{}, err
+	}
+
+	err = c.unmarshal(body, &user)
+
+	return user, err
+}
+
+// ListRecentFiles retrieves the first page of the files recently used by the current user.
+// DriveItems.NextLink is set if there are more; ListAllRecentFiles retrieves all pages.
 func (c *OneDriveClient) ListRecentFiles() (driveItems DriveItems, err error) {
-	body, err := c.Get("https://graph.microsoft.com/v1.0/me/drive/recent")
+	body, err := c.Get(c.buildURL("/me/drive/recent"))
+	if err != nil {
+		return DriveItems{}, err
+	}
+
+	err = c.unmarshal(body, &driveItems)
+
+	return driveItems, err
+}
+
+// ListAllRecentFiles retrieves all files recently used by the current user,
+// following every NextLink.
+func (c *OneDriveClient) ListAllRecentFiles(ctx context.Context) ([]DriveItem, error) {
+	return c.listAll(ctx, c.buildURL("/me/drive/recent"))
+}
+
+// listAll retrieves the items of the collection at url and of all following pages.
+func (c *OneDriveClient) listAll(ctx context.Context, url string) (items []DriveItem, err error) {
+	for url != "" {
+		body, err := c.get(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+
+		var page DriveItems
+		err = c.unmarshal(body, &page)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page.Value...)
+		url = page.NextLink
+	}
+
+	return items, nil
+}
+
+// GetItemByID retrieves the DriveItem identified by itemID.
+// The response is cached if the client has a cache, see WithCache.
+func (c *OneDriveClient) GetItemByID(ctx context.Context, itemID string) (driveItem DriveItem, err error) {
+	body, err := c.getCached(ctx, c.driveURL("/items/"+url.PathEscape(itemID)))
+	if err != nil {
+		return DriveItem{}, err
+	}
+
+	err = c.unmarshal(body, &driveItem)
+
+	return driveItem, err
+}
+
+// GetItemByPath retrieves the DriveItem at path relative to the root of the drive.
+// The response is cached if the client has a cache, see WithCache.
+func (c *OneDriveClient) GetItemByPath(ctx context.Context, path string) (driveItem DriveItem, err error) {
+	body, err := c.getCached(ctx, c.driveURL(itemByPath(path)))
+	if err != nil {
+		return DriveItem{}, err
+	}
+
+	err = c.unmarshal(body, &driveItem)
+
+	return driveItem, err
+}
+
+// ResolveRemoteItem retrieves the item that item refers to if it has the RemoteItem facet,
+// such as an item shared with the current user, from the drive that contains it.
+// Otherwise item is returned unchanged.
+func (c *OneDriveClient) ResolveRemoteItem(ctx context.Context, item DriveItem) (DriveItem, error) {
+	remote := item.RemoteItem
+	if remote.Id == "" {
+		return item, nil
+	}
+	if remote.ParentReference.DriveId == "" {
+		return DriveItem{}, fmt.Errorf("remote item %s has no drive ID", remote.Id)
+	}
+
+	return c.ForDrive(remote.ParentReference.DriveId).GetItemByID(ctx, remote.Id)
+}
+
+// itemByPath returns the address of the item at path relative to the drive,
+// e.g., /root:/Documents/Reports: for /Documents/Reports, or /root for /.
+func itemByPath(path string) string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return "/root"
+	}
+
+	parts := strings.Split(path, "/")
+	for i := range parts {
+		parts[i] = url.PathEscape(parts[i])
+	}
+
+	return "/root:/" + strings.Join(parts, "/") + ":"
+}
+
+// ListChildren retrieves the children of the folder identified by itemID.
+func (c *OneDriveClient) ListChildren(ctx context.Context, itemID string) (driveItems DriveItems, err error) {
+	body, err := c.get(ctx, c.driveURL("/items/"+url.PathEscape(itemID)+"/children"))
 	if err != nil {
 		return DriveItems{}, err
 	}
 
-	err = json.Unmarshal(body, &driveItems)
+	err = c.unmarshal(body, &driveItems)
 
 	return driveItems, err
 }
 
+// GetDriveRoot retrieves the root folder of the drive, whose Id can be used
+// with the methods that identify an item by ID.
+func (c *OneDriveClient) GetDriveRoot(ctx context.Context) (driveItem DriveItem, err error) {
+	return c.GetItemByPath(ctx, "/")
+}
+
+// GetDriveRootChildren retrieves the children of the root folder of the drive,
+// like ListChildren for the root folder.
+func (c *OneDriveClient) GetDriveRootChildren(ctx context.Context) (driveItems DriveItems, err error) {
+	body, err := c.get(ctx, c.driveURL(itemByPath("/")+"/children"))
+	if err != nil {
+		return DriveItems{}, err
+	}
+
+	err = c.unmarshal(body, &driveItems)
+
+	return driveItems, err
+}
+
+// ForDrive returns a client scoped to the drive identified by driveID.
+// Drive operations on the returned client use /drives/{driveID} instead of /me/drive.
+// The returned client shares the HTTP client and token of c.
+func (c *OneDriveClient) ForDrive(driveID string) *OneDriveClient {
+	scoped := *c
+	scoped.drivePath = "/drives/" + url.PathEscape(driveID)
+
+	return &scoped
+}
+
+// driveURL returns the Graph URL for path within the drive of the client.
+func (c *OneDriveClient) driveURL(path string) string {
+	return c.buildURL(c.drive() + path)
+}
+
+// buildURL returns the URL of path, e.g., /me/drive, in the Graph API version of the client.
+func (c *OneDriveClient) buildURL(path string) string {
+	return c.host() + "/" + string(c.version()) + path
+}
+
+// version returns the Graph API version of the client.
+func (c *OneDriveClient) version() GraphVersion {
+	if c.graphVersion == "" {
+		return GraphV1
+	}
+
+	return c.graphVersion
+}
+
+// betaURL returns the URL of path in the beta Graph API.
+func (c *OneDriveClient) betaURL(path string) string {
+	return c.host() + "/beta" + path
+}
+
+// host returns the scheme and host of the Graph API.
+func (c *OneDriveClient) host() string {
+	if c.graphHost == "" {
+		return GraphBaseURL
+	}
+
+	return c.graphHost
+}
+
+// drive returns the path of the drive of the client.
+func (c *OneDriveClient) drive() string {
+	if c.drivePath == "" {
+		return "/me/drive"
+	}
+
+	return c.drivePath
+}
+
 type OneDriveClient struct {
 	httpClient *http.Client
+
+	// streamClient is httpClient without an overall timeout, for requests that transfer content
+	streamClient *http.Client
+
+	// uploadClient sends requests to pre-authenticated URLs, such as upload URLs, without a token
+	uploadClient *http.Client
+
+	// tokenSource provides the tokens used by httpClient
+	tokenSource *tokenRecorder
+
+	// metrics records the calls made by the client
+	metrics Metrics
+
+	// requestID is sent as the client-request-id header, if not empty
+	requestID string
+
+	// breaker stops requests during outages, if not nil
+	breaker *circuitBreaker
+
+	// downloadConcurrency limits concurrent downloads of several files
+	downloadConcurrency int
+
+	// verifyOnDownload verifies the hash of downloaded files
+	verifyOnDownload bool
+
+	// drivePath is the base path for drive operations, /me/drive if empty
+	drivePath string
+
+	// graphHost is the scheme and host of the Graph API, GraphBaseURL if empty
+	graphHost string
+
+	// graphVersion is the version of the Graph API, GraphV1 if empty
+	graphVersion GraphVersion
+
+	// inflight deduplicates concurrent identical GET requests, if not nil
+	inflight *singleflight.Group
+
+	// cache caches DriveItem metadata reads, if not nil
+	cache *itemCache
+
+	// anchorMailbox is sent as the X-AnchorMailbox header of Graph requests, if not empty
+	anchorMailbox string
+
+	// pollInterval and maxPollInterval control PollOperation, defaults if zero
+	pollInterval    time.Duration
+	maxPollInterval time.Duration
+
+	// detectContentType determines the Content-Type of uploads, DetectContentType if nil
+	detectContentType func(name string, head []byte) string
+
+	// beforeRequest and afterResponse are called around every request
+	beforeRequest []func(req *http.Request) error
+	afterResponse []func(req *http.Request, resp *http.Response, err error)
+
+	// strictJSON fails decoding responses with unknown fields
+	strictJSON bool
 }
 
 const (
-	msBase        = "https://login.microsoftonline.com/common/oauth2/v2.0"
-	msAuthURL     = msBase + "/authorize"
-	msTokenURL    = msBase + "/token"
-	myRedirectURL = "https://login.microsoftonline.com/common/oauth2/nativeclient"
+	defaultGraphHost = "https://graph.microsoft.com"
+	defaultClientID  = "c32f556d-11cc-45ce-9b73-37f701abf48c"
+	defaultTenantID  = "common"
 )
 
 // New create an initialized OneDriveClient using the token from tokenFileName.
 // If tokenFileName doesn't exist, then a token is requested and saved in the file.
-// User interaction is required to request a token for the first time.
-func New(tokenFileName string) *OneDriveClient {
-	ctx := context.Background()
-
-	conf := &oauth2.Config{
-		ClientID: "c32f556d-11cc-45ce-9b73-37f701abf48c",
-		// TODO: need offline_access? AuthCodeURL offline?
-		Scopes: []string{"Files.Read.All", "offline_access"},
-		Endpoint: oauth2.Endpoint{
-			AuthURL:  msAuthURL,
-			TokenURL: msTokenURL,
-		},
-		RedirectURL: myRedirectURL,
+// User interaction is required to request a token for the first time,
+// which is requested with the default scopes, see Config.Scopes.
+func New(tokenFileName string, opts ...Option) *OneDriveClient {
+	client, err := NewWithConfig(Config{TokenFile: tokenFileName}, opts...)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	client := &OneDriveClient{}
+	return client
+}
 
-	// try to get a token from the file
-	token, _ := readTokenFromFile(tokenFileName)
+// requestToken interactively asks the user to authenticate and
+// exchanges the resulting authorization code for a token.
+func requestToken(ctx context.Context, conf *oauth2.Config, exchangeTimeout time.Duration) (*oauth2.Token, error) {
+	// generate random state to detect Cross-Site Request Forgery
+	state := randomBytesBase64(32)
 
-	if token == nil {
-		// could not get token from file
+	// get authentication URL for offline access
+	authURL := conf.AuthCodeURL(state, oauth2.AccessTypeOffline)
 
-		// generate random state to detect Cross-Site Request Forgery
-		state := randomBytesBase64(32)
+	// instruct the user to vist the authentication URL
+	fmt.Println("Vist the following URL in a browser to authenticate this application")
+	fmt.Println("After authentication, copy the response URL from the browser")
+	fmt.Println(authURL)
 
-		// get authentication URL for offline access
-		authURL := conf.AuthCodeURL(state, oauth2.AccessTypeOffline)
+	// read the response URL
+	fmt.Println("Enter the response URL:")
+	responseString, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	responseString = strings.TrimSpace(responseString)
 
-		// instruct the user to vist the authentication URL
-		fmt.Println("Vist the following URL in a browser to authenticate this application")
-		fmt.Println("After authentication, copy the response URL from the browser")
-		fmt.Println(authURL)
+	// parse the response URL
+	responseURL, err := url.Parse(responseString)
+	if err != nil {
+		return nil, err
+	}
+	// get and compare state to prevent Cross-Site Request Forgery
+	responseState := responseURL.Query().Get("state")
+	if responseState != state {
+		return nil, errors.New("state mismatch, potenial Cross-Site Request Forgery (CSRF)")
+	}
 
-		// read the response URL
-		fmt.Println("Enter the response URL:")
-		responseString, err := bufio.NewReader(os.Stdin).ReadString('\n')
-		if err != nil {
-			log.Fatal(err)
-		}
-		responseString = strings.TrimSpace(responseString)
+	// get authorization code
+	code := responseURL.Query().Get("code")
 
-		// parse the response URL
-		responseURL, err := url.Parse(responseString)
-		if err != nil {
-			log.Fatal(err)
-		}
-		// get and compare state to prevent Cross-Site Request Forgery
-		responseState := responseURL.Query().Get("state")
-		if responseState != state {
-			log.Fatalln("state mismatch, potenial Cross-Site Request Forgery (CSRF)")
-		}
+	// exchange authorize code for token
+	return exchangeToken(ctx, conf, code, exchangeTimeout)
+}
 
-		// get authorization code
-		code := responseURL.Query().Get("code")
+// defaultExchangeTimeout is the default limit for exchanging the authorization code.
+const defaultExchangeTimeout = 30 * time.Second
 
-		// exchange authorize code for token
-		token, err = conf.Exchange(ctx, code)
-		if err != nil {
-			log.Fatal(err)
-		}
+// exchangeToken exchanges code for a token, giving up after timeout,
+// so an unreachable token endpoint does not block forever.
+func exchangeToken(ctx context.Context, conf *oauth2.Config, code string, timeout time.Duration) (*oauth2.Token, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 
-		// save the token to a file
-		writeTokenToFile(tokenFileName, token)
+	token, err := conf.Exchange(ctx, code)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("token exchange timed out after %v: %w", timeout, err)
 	}
 
-	// create HTTP client using the provided token
-	client.httpClient = conf.Client(ctx, token)
-
-	return client
+	return token, err
 }
@@ -0,0 +1,303 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bnixon67/onedrive"
+	"github.com/bnixon67/onedrive/onedrivetest"
+	"golang.org/x/oauth2"
+)
+
+// fixture is a TestServer with a drive of four files, served in pages of two items:
+//
+//	/docs/a.txt
+//	/docs/b.txt
+//	/docs/sub/c.txt
+//	/readme.md
+type fixture struct {
+	server *onedrivetest.TestServer
+	client *onedrive.OneDriveClient
+	docs   onedrive.DriveItem
+	a      onedrive.DriveItem
+	c      onedrive.DriveItem
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	s := onedrivetest.NewTestServer()
+	t.Cleanup(s.Close)
+	s.SetPageSize(2)
+
+	f := &fixture{server: s, client: onedrivetest.NewTestClient(s)}
+	f.docs = s.AddDriveItem(onedrive.DriveItem{Name: "docs", Folder: &onedrive.Folder{}})
+	f.a = s.AddFile(f.docs.Id, "a.txt", []byte("aaaa"))
+	s.AddFile(f.docs.Id, "b.txt", []byte("bb"))
+	sub := s.AddDriveItem(onedrive.DriveItem{Name: "sub", Folder: &onedrive.Folder{},
+		ParentReference: &onedrive.ParentReference{Id: f.docs.Id}})
+	f.c = s.AddFile(sub.Id, "c.txt", []byte("c"))
+	s.AddFile(onedrivetest.RootID, "readme.md", []byte("# readme"))
+
+	return f
+}
+
+// names returns the names of items separated by commas.
+func names(items []onedrive.DriveItem) string {
+	var names []string
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+
+	return strings.Join(names, ",")
+}
+
+// clientCalls call a method of the client of a fixture and summarize the decoded result.
+var clientCalls = []struct {
+	name string
+	call func(ctx context.Context, f *fixture) (string, error)
+	want string
+}{
+	{"GetMyDrive", func(ctx context.Context, f *fixture) (string, error) {
+		drive, err := f.client.GetMyDrive()
+		return drive.Id + " " + drive.DriveType, err
+	}, "onedrivetest-drive personal"},
+	{"ListMyDrives", func(ctx context.Context, f *fixture) (string, error) {
+		drives, err := f.client.ListMyDrives()
+		return fmt.Sprint(len(drives.Value)), err
+	}, "1"},
+	{"GetMyProfile", func(ctx context.Context, f *fixture) (string, error) {
+		user, err := f.client.GetMyProfile(ctx)
+		return user.DisplayName, err
+	}, "Test User"},
+	{"GetItemByID", func(ctx context.Context, f *fixture) (string, error) {
+		item, err := f.client.GetItemByID(ctx, f.a.Id)
+		return fmt.Sprint(item.Name, " ", item.Size), err
+	}, "a.txt 4"},
+	{"GetItemByPath", func(ctx context.Context, f *fixture) (string, error) {
+		item, err := f.client.GetItemByPath(ctx, "/docs/sub/c.txt")
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprint(item.Id == f.c.Id, " ", item.ParentReference.Path), nil
+	}, "true /drive/root:/docs/sub"},
+	{"GetDriveRoot", func(ctx context.Context, f *fixture) (string, error) {
+		item, err := f.client.GetDriveRoot(ctx)
+		return item.Id, err
+	}, "root"},
+	{"ListChildren first page", func(ctx context.Context, f *fixture) (string, error) {
+		items, err := f.client.ListChildren(ctx, f.docs.Id)
+		return fmt.Sprint(names(items.Value), " ", items.NextLink != ""), err
+	}, "a.txt,b.txt true"},
+	{"GetDriveRootChildren", func(ctx context.Context, f *fixture) (string, error) {
+		items, err := f.client.GetDriveRootChildren(ctx)
+		return fmt.Sprint(names(items.Value), " ", items.NextLink != ""), err
+	}, "docs,readme.md false"},
+	{"ListRecentFiles first page", func(ctx context.Context, f *fixture) (string, error) {
+		items, err := f.client.ListRecentFiles()
+		return fmt.Sprint(names(items.Value), " ", items.NextLink != ""), err
+	}, "a.txt,b.txt true"},
+	{"ListAllRecentFiles", func(ctx context.Context, f *fixture) (string, error) {
+		items, err := f.client.ListAllRecentFiles(ctx)
+		return names(items), err
+	}, "a.txt,b.txt,c.txt,readme.md"},
+	{"GetDelta", func(ctx context.Context, f *fixture) (string, error) {
+		items, deltaLink, err := f.client.GetDelta(ctx, "")
+		return fmt.Sprint(names(items), " ", deltaLink != ""), err
+	}, "root,docs,a.txt,b.txt,sub,c.txt,readme.md true"},
+	{"GetFolderSize", func(ctx context.Context, f *fixture) (string, error) {
+		size, err := f.client.GetFolderSize(ctx, f.docs.Id)
+		return fmt.Sprint(size), err
+	}, "7"},
+	{"DownloadFile", func(ctx context.Context, f *fixture) (string, error) {
+		var buf bytes.Buffer
+		err := f.client.DownloadFile(ctx, f.a.Id, &buf)
+		return buf.String(), err
+	}, "aaaa"},
+	{"CreateFolder", func(ctx context.Context, f *fixture) (string, error) {
+		item, err := f.client.CreateFolder(ctx, f.docs.Id, "new")
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprint(item.Name, " ", item.Folder != nil, " ", item.ParentReference.Path), nil
+	}, "new true /drive/root:/docs"},
+	{"CreateFolderByPath", func(ctx context.Context, f *fixture) (string, error) {
+		item, err := f.client.CreateFolderByPath(ctx, "/docs/x/y")
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprint(item.Name, " ", item.ParentReference.Path), nil
+	}, "y /drive/root:/docs/x"},
+	{"UploadSmallFile", func(ctx context.Context, f *fixture) (string, error) {
+		item, err := f.client.UploadSmallFile(ctx, "/docs/d.txt", strings.NewReader("ddd"), "text/plain")
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprint(item.Name, " ", item.Size, " ", item.File.MimeType), nil
+	}, "d.txt 3 text/plain"},
+	{"DeleteItem", func(ctx context.Context, f *fixture) (string, error) {
+		err := f.client.DeleteItem(ctx, f.a.Id)
+		if err != nil {
+			return "", err
+		}
+		_, err = f.client.GetItemByID(ctx, f.a.Id)
+		return fmt.Sprint(onedrive.IsNotFound(err)), nil
+	}, "true"},
+}
+
+func TestClientMethods(t *testing.T) {
+	for _, tt := range clientCalls {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+
+			got, err := tt.call(context.Background(), f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientMethodsRateLimited(t *testing.T) {
+	for _, tt := range clientCalls {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			ctx := context.Background()
+
+			// the first request succeeds, the rest are throttled
+			f.server.SimulateRateLimit(1)
+			_, err := f.client.GetMyProfile(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = tt.call(ctx, f)
+			if code, _ := onedrive.HTTPStatusCode(err); code != http.StatusTooManyRequests {
+				t.Errorf("err = %v, want status %d", err, http.StatusTooManyRequests)
+			}
+		})
+	}
+}
+
+func TestClientMethodsRateLimitedWhilePaging(t *testing.T) {
+	f := newFixture(t)
+
+	// the first page succeeds, the second is throttled
+	f.server.SimulateRateLimit(1)
+	items, err := f.client.ListAllRecentFiles(context.Background())
+	if code, _ := onedrive.HTTPStatusCode(err); code != http.StatusTooManyRequests {
+		t.Errorf("err = %v, want status %d", err, http.StatusTooManyRequests)
+	}
+	if items != nil {
+		t.Errorf("items = %v, want none", names(items))
+	}
+}
+
+func TestClientMethodsNotFound(t *testing.T) {
+	const missing = "missing"
+
+	tests := []struct {
+		name string
+		call func(ctx context.Context, c *onedrive.OneDriveClient) error
+	}{
+		{"GetItemByID", func(ctx context.Context, c *onedrive.OneDriveClient) error {
+			_, err := c.GetItemByID(ctx, missing)
+			return err
+		}},
+		{"GetItemByPath", func(ctx context.Context, c *onedrive.OneDriveClient) error {
+			_, err := c.GetItemByPath(ctx, "/docs/missing.txt")
+			return err
+		}},
+		{"ListChildren", func(ctx context.Context, c *onedrive.OneDriveClient) error {
+			_, err := c.ListChildren(ctx, missing)
+			return err
+		}},
+		{"GetFolderSize", func(ctx context.Context, c *onedrive.OneDriveClient) error {
+			_, err := c.GetFolderSize(ctx, missing)
+			return err
+		}},
+		{"DownloadFile", func(ctx context.Context, c *onedrive.OneDriveClient) error {
+			return c.DownloadFile(ctx, missing, &bytes.Buffer{})
+		}},
+		{"CreateFolder", func(ctx context.Context, c *onedrive.OneDriveClient) error {
+			_, err := c.CreateFolder(ctx, missing, "new")
+			return err
+		}},
+		{"DeleteItem", func(ctx context.Context, c *onedrive.OneDriveClient) error {
+			return c.DeleteItem(ctx, missing)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+
+			err := tt.call(context.Background(), f.client)
+			if !onedrive.IsNotFound(err) {
+				t.Errorf("err = %v, want not found", err)
+			}
+			if code, _ := onedrive.HTTPStatusCode(err); code != http.StatusNotFound {
+				t.Errorf("status = %d, want %d", code, http.StatusNotFound)
+			}
+		})
+	}
+}
+
+func TestListAllRecentFiles(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RequestURI())
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.RequestURI() {
+		case "/v1.0/me/drive/recent":
+			fmt.Fprintf(w, `{"value":[{"id":"1","name":"one"},{"id":"2","name":"two"}],
+				"@odata.nextLink":"http://%s/v1.0/me/drive/recent?$skiptoken=page2"}`, r.Host)
+		case "/v1.0/me/drive/recent?$skiptoken=page2":
+			fmt.Fprint(w, `{"value":[{"id":"3","name":"three"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	token := &oauth2.Token{AccessToken: "token", Expiry: time.Now().Add(time.Hour)}
+	c := onedrive.NewFromOAuthConfig(context.Background(), &oauth2.Config{}, token, onedrive.WithBaseURL(server.URL))
+
+	items, err := c.ListAllRecentFiles(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(items); got != "one,two,three" {
+		t.Errorf("items = %s, want one,two,three", got)
+	}
+	if len(requests) != 2 {
+		t.Errorf("requests = %v, want the first page and the next link", requests)
+	}
+}
@@ -0,0 +1,616 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package onedrivetest provides utilities for testing code that uses package onedrive.
+package onedrivetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bnixon67/onedrive"
+)
+
+// ErrNoResponse is returned by a MockClient method without a response set for its arguments.
+var ErrNoResponse = errors.New("onedrivetest: no response set")
+
+// response is a value and error returned by a MockClient method.
+type response struct {
+	value interface{}
+	err   error
+}
+
+// MockClient implements onedrive.Client with responses set per method and key.
+// The key is the natural identifier of the call, such as the item ID; methods
+// without one use an empty key. Methods with a writer write the []byte response to it.
+// The zero value is ready to use and a MockClient is safe for concurrent use.
+type MockClient struct {
+	mu        sync.Mutex
+	responses map[string]response
+}
+
+// MockClient must implement onedrive.Client
+var _ onedrive.Client = (*MockClient)(nil)
+
+// Set sets the value and error returned by method for key. value must have
+// the type of the first result of method, or be []byte for methods with a writer.
+// The specific Set methods, such as SetGetItemByIDResponse, are preferred.
+func (m *MockClient) Set(method, key string, value interface{}, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.responses == nil {
+		m.responses = make(map[string]response)
+	}
+	m.responses[method+"\x00"+key] = response{value, err}
+}
+
+// get returns the value and error set for method and key.
+func (m *MockClient) get(method, key string) (interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	resp, ok := m.responses[method+"\x00"+key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s(%q)", ErrNoResponse, method, key)
+	}
+
+	return resp.value, resp.err
+}
+
+// write writes the []byte value set for method and key to w.
+func (m *MockClient) write(method, key string, w io.Writer) error {
+	v, err := m.get(method, key)
+	if err != nil {
+		return err
+	}
+
+	b, _ := v.([]byte)
+	_, err = w.Write(b)
+
+	return err
+}
+
+// SetGetItemByIDResponse sets the result of GetItemByID for itemID.
+func (m *MockClient) SetGetItemByIDResponse(itemID string, item onedrive.DriveItem, err error) {
+	m.Set("GetItemByID", itemID, item, err)
+}
+
+// SetGetItemByPathResponse sets the result of GetItemByPath for path.
+func (m *MockClient) SetGetItemByPathResponse(path string, item onedrive.DriveItem, err error) {
+	m.Set("GetItemByPath", path, item, err)
+}
+
+// SetListChildrenResponse sets the result of ListChildren for itemID.
+func (m *MockClient) SetListChildrenResponse(itemID string, items onedrive.DriveItems, err error) {
+	m.Set("ListChildren", itemID, items, err)
+}
+
+// SetCreateFolderResponse sets the result of CreateFolder for parentID and name.
+func (m *MockClient) SetCreateFolderResponse(parentID, name string, item onedrive.DriveItem, err error) {
+	m.Set("CreateFolder", parentID+"/"+name, item, err)
+}
+
+// SetDownloadFileResponse sets the content written and the error returned by DownloadFile for itemID.
+func (m *MockClient) SetDownloadFileResponse(itemID string, content []byte, err error) {
+	m.Set("DownloadFile", itemID, content, err)
+}
+
+// SetDeleteItemResponse sets the error returned by DeleteItem for itemID.
+func (m *MockClient) SetDeleteItemResponse(itemID string, err error) {
+	m.Set("DeleteItem", itemID, nil, err)
+}
+
+func (m *MockClient) Get(url string) ([]byte, error) {
+	v, err := m.get("Get", url)
+	body, _ := v.([]byte)
+	return body, err
+}
+
+func (m *MockClient) GetMyDrive() (onedrive.Drive, error) {
+	v, err := m.get("GetMyDrive", "")
+	drive, _ := v.(onedrive.Drive)
+	return drive, err
+}
+
+func (m *MockClient) GetSystemDriveInfo(ctx context.Context) (onedrive.DriveInfo, error) {
+	v, err := m.get("GetSystemDriveInfo", "")
+	info, _ := v.(onedrive.DriveInfo)
+	return info, err
+}
+
+func (m *MockClient) GetSpecialFolder(ctx context.Context, name onedrive.SpecialFolderName) (onedrive.DriveItem, error) {
+	v, err := m.get("GetSpecialFolder", string(name))
+	item, _ := v.(onedrive.DriveItem)
+	return item, err
+}
+
+func (m *MockClient) ListSpecialFolders(ctx context.Context) ([]onedrive.SpecialFolder, error) {
+	v, err := m.get("ListSpecialFolders", "")
+	folders, _ := v.([]onedrive.SpecialFolder)
+	return folders, err
+}
+
+func (m *MockClient) ListMyDrives() (onedrive.Drives, error) {
+	v, err := m.get("ListMyDrives", "")
+	drives, _ := v.(onedrive.Drives)
+	return drives, err
+}
+
+func (m *MockClient) GetMyProfile(ctx context.Context) (onedrive.User, error) {
+	v, err := m.get("GetMyProfile", "")
+	user, _ := v.(onedrive.User)
+	return user, err
+}
+
+func (m *MockClient) ListRecentFiles() (onedrive.DriveItems, error) {
+	v, err := m.get("ListRecentFiles", "")
+	items, _ := v.(onedrive.DriveItems)
+	return items, err
+}
+
+func (m *MockClient) ListAllRecentFiles(ctx context.Context) ([]onedrive.DriveItem, error) {
+	v, err := m.get("ListAllRecentFiles", "")
+	items, _ := v.([]onedrive.DriveItem)
+	return items, err
+}
+
+func (m *MockClient) GetItemByID(ctx context.Context, itemID string) (onedrive.DriveItem, error) {
+	v, err := m.get("GetItemByID", itemID)
+	item, _ := v.(onedrive.DriveItem)
+	return item, err
+}
+
+func (m *MockClient) GetItemByPath(ctx context.Context, path string) (onedrive.DriveItem, error) {
+	v, err := m.get("GetItemByPath", path)
+	item, _ := v.(onedrive.DriveItem)
+	return item, err
+}
+
+func (m *MockClient) ListChildren(ctx context.Context, itemID string) (onedrive.DriveItems, error) {
+	v, err := m.get("ListChildren", itemID)
+	items, _ := v.(onedrive.DriveItems)
+	return items, err
+}
+
+func (m *MockClient) GetDriveRoot(ctx context.Context) (onedrive.DriveItem, error) {
+	v, err := m.get("GetDriveRoot", "")
+	item, _ := v.(onedrive.DriveItem)
+	return item, err
+}
+
+func (m *MockClient) GetDriveRootChildren(ctx context.Context) (onedrive.DriveItems, error) {
+	v, err := m.get("GetDriveRootChildren", "")
+	items, _ := v.(onedrive.DriveItems)
+	return items, err
+}
+
+func (m *MockClient) GraphSearch(ctx context.Context, query string, entityTypes []string, opts onedrive.GraphSearchOptions) (onedrive.SearchResponse, error) {
+	v, err := m.get("GraphSearch", query)
+	resp, _ := v.(onedrive.SearchResponse)
+	return resp, err
+}
+
+func (m *MockClient) GetDeltaPage(ctx context.Context, link string) (onedrive.DeltaItems, error) {
+	v, err := m.get("GetDeltaPage", link)
+	items, _ := v.(onedrive.DeltaItems)
+	return items, err
+}
+
+// GetDelta returns the Value and DeltaLink of the DeltaItems set for deltaLink.
+func (m *MockClient) GetDelta(ctx context.Context, deltaLink string) ([]onedrive.DriveItem, string, error) {
+	v, err := m.get("GetDelta", deltaLink)
+	items, _ := v.(onedrive.DeltaItems)
+	return items.Value, items.DeltaLink, err
+}
+
+func (m *MockClient) ListAllDriveItems(ctx context.Context, driveID string) ([]onedrive.DriveItem, error) {
+	v, err := m.get("ListAllDriveItems", driveID)
+	items, _ := v.([]onedrive.DriveItem)
+	return items, err
+}
+
+// ListAllDriveItemsChan sends the items set for ListAllDriveItems with driveID on the channel.
+func (m *MockClient) ListAllDriveItemsChan(ctx context.Context, driveID string) (<-chan onedrive.DriveItem, <-chan error) {
+	v, err := m.get("ListAllDriveItems", driveID)
+	items, _ := v.([]onedrive.DriveItem)
+
+	ch := make(chan onedrive.DriveItem)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		defer close(ch)
+
+		for _, item := range items {
+			select {
+			case ch <- item:
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			}
+		}
+		if err != nil {
+			errc <- err
+		}
+	}()
+
+	return ch, errc
+}
+
+func (m *MockClient) GetDriveActivities(ctx context.Context, opts onedrive.ActivityOptions) ([]onedrive.ItemActivity, error) {
+	v, err := m.get("GetDriveActivities", "")
+	activities, _ := v.([]onedrive.ItemActivity)
+	return activities, err
+}
+
+func (m *MockClient) CreateFolder(ctx context.Context, parentID, name string) (onedrive.DriveItem, error) {
+	v, err := m.get("CreateFolder", parentID+"/"+name)
+	item, _ := v.(onedrive.DriveItem)
+	return item, err
+}
+
+func (m *MockClient) CreateFolderByPath(ctx context.Context, path string) (onedrive.DriveItem, error) {
+	v, err := m.get("CreateFolderByPath", path)
+	item, _ := v.(onedrive.DriveItem)
+	return item, err
+}
+
+func (m *MockClient) GetItemsModifiedSince(ctx context.Context, folderID string, since time.Time) ([]onedrive.DriveItem, error) {
+	v, err := m.get("GetItemsModifiedSince", folderID)
+	items, _ := v.([]onedrive.DriveItem)
+	return items, err
+}
+
+func (m *MockClient) GetFolderSize(ctx context.Context, folderID string) (int64, error) {
+	v, err := m.get("GetFolderSize", folderID)
+	size, _ := v.(int64)
+	return size, err
+}
+
+// CopyItem uses the item ID and destination parent ID joined by a slash as key.
+func (m *MockClient) CopyItem(ctx context.Context, itemID, dstParentID, newName string) (onedrive.DriveItem, error) {
+	v, err := m.get("CopyItem", itemID+"/"+dstParentID)
+	item, _ := v.(onedrive.DriveItem)
+	return item, err
+}
+
+// CrossDriveCopyItem uses the source item ID and destination parent ID joined by a slash as key.
+func (m *MockClient) CrossDriveCopyItem(ctx context.Context, srcDriveID, srcItemID, dstDriveID, dstParentID, newName string) (onedrive.DriveItem, error) {
+	v, err := m.get("CrossDriveCopyItem", srcItemID+"/"+dstParentID)
+	item, _ := v.(onedrive.DriveItem)
+	return item, err
+}
+
+// MoveItem uses the item ID and destination parent ID joined by a slash as key.
+func (m *MockClient) MoveItem(ctx context.Context, itemID, dstParentID, newName string) (onedrive.DriveItem, error) {
+	v, err := m.get("MoveItem", itemID+"/"+dstParentID)
+	item, _ := v.(onedrive.DriveItem)
+	return item, err
+}
+
+// CrossDriveMoveItem uses the source item ID and destination parent ID joined by a slash as key.
+func (m *MockClient) CrossDriveMoveItem(ctx context.Context, srcDriveID, srcItemID, dstDriveID, dstParentID, newName string) (onedrive.DriveItem, error) {
+	v, err := m.get("CrossDriveMoveItem", srcItemID+"/"+dstParentID)
+	item, _ := v.(onedrive.DriveItem)
+	return item, err
+}
+
+func (m *MockClient) RotateImage(ctx context.Context, itemID string, degrees int) (onedrive.DriveItem, error) {
+	v, err := m.get("RotateImage", itemID)
+	item, _ := v.(onedrive.DriveItem)
+	return item, err
+}
+
+// MergeFolder uses the source and destination folder IDs joined by a slash as key.
+func (m *MockClient) MergeFolder(ctx context.Context, srcFolderID, dstFolderID string, conflictPolicy onedrive.ConflictBehavior, deleteSource bool) (onedrive.MergeReport, error) {
+	v, err := m.get("MergeFolder", srcFolderID+"/"+dstFolderID)
+	report, _ := v.(onedrive.MergeReport)
+	return report, err
+}
+
+func (m *MockClient) DeleteItem(ctx context.Context, itemID string) error {
+	_, err := m.get("DeleteItem", itemID)
+	return err
+}
+
+func (m *MockClient) PermanentDelete(ctx context.Context, itemID string) error {
+	_, err := m.get("PermanentDelete", itemID)
+	return err
+}
+
+func (m *MockClient) RestoreFromRecycleBin(ctx context.Context, itemID string) (onedrive.DriveItem, error) {
+	v, err := m.get("RestoreFromRecycleBin", itemID)
+	item, _ := v.(onedrive.DriveItem)
+	return item, err
+}
+
+func (m *MockClient) UploadFile(ctx context.Context, path string, r io.ReadSeeker, size int64, opts onedrive.UploadOptions) (onedrive.DriveItem, error) {
+	v, err := m.get("UploadFile", path)
+	item, _ := v.(onedrive.DriveItem)
+	return item, err
+}
+
+func (m *MockClient) GetItemDescription(ctx context.Context, itemID string) (string, error) {
+	v, err := m.get("GetItemDescription", itemID)
+	description, _ := v.(string)
+	return description, err
+}
+
+func (m *MockClient) SetItemDescription(ctx context.Context, itemID, description string) error {
+	_, err := m.get("SetItemDescription", itemID)
+	return err
+}
+
+func (m *MockClient) SetFileSystemInfo(ctx context.Context, itemID string, created, modified time.Time) (onedrive.DriveItem, error) {
+	v, err := m.get("SetFileSystemInfo", itemID)
+	item, _ := v.(onedrive.DriveItem)
+	return item, err
+}
+
+func (m *MockClient) UploadSmallFile(ctx context.Context, path string, r io.Reader, contentType string) (onedrive.DriveItem, error) {
+	v, err := m.get("UploadSmallFile", path)
+	item, _ := v.(onedrive.DriveItem)
+	return item, err
+}
+
+func (m *MockClient) CreateUploadSession(ctx context.Context, path string) (onedrive.UploadSession, error) {
+	v, err := m.get("CreateUploadSession", path)
+	session, _ := v.(onedrive.UploadSession)
+	return session, err
+}
+
+func (m *MockClient) UploadLargeFile(ctx context.Context, path string, r io.Reader, size int64, opts onedrive.UploadLargeFileOptions) (onedrive.DriveItem, error) {
+	v, err := m.get("UploadLargeFile", path)
+	item, _ := v.(onedrive.DriveItem)
+	return item, err
+}
+
+func (m *MockClient) UpdateItemContent(ctx context.Context, itemID string, r io.ReadSeeker, size int64) (onedrive.DriveItem, error) {
+	v, err := m.get("UpdateItemContent", itemID)
+	item, _ := v.(onedrive.DriveItem)
+	return item, err
+}
+
+func (m *MockClient) DownloadFile(ctx context.Context, itemID string, w io.Writer) error {
+	return m.write("DownloadFile", itemID, w)
+}
+
+// DownloadToPath writes the response for itemID to localPath. Without opts.Overwrite,
+// it fails like the client if localPath exists.
+func (m *MockClient) DownloadToPath(ctx context.Context, itemID string, localPath string, opts onedrive.DownloadOptions) error {
+	v, err := m.get("DownloadToPath", itemID)
+	if err != nil {
+		return err
+	}
+
+	flag := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if opts.Overwrite {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	file, err := os.OpenFile(localPath, flag, 0666)
+	if err != nil {
+		return err
+	}
+
+	b, _ := v.([]byte)
+	_, err = file.Write(b)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// DownloadAsZip uses the item IDs joined by commas as key.
+func (m *MockClient) DownloadAsZip(ctx context.Context, itemIDs []string, w io.Writer) error {
+	return m.write("DownloadAsZip", strings.Join(itemIDs, ","), w)
+}
+
+// ConvertToFormat uses the item ID and format joined by a slash as key.
+func (m *MockClient) ConvertToFormat(ctx context.Context, itemID string, format onedrive.ConvertFormat, w io.Writer) error {
+	return m.write("ConvertToFormat", itemID+"/"+string(format), w)
+}
+
+func (m *MockClient) VerifyFileIntegrity(ctx context.Context, itemID string, localPath string) (bool, error) {
+	v, err := m.get("VerifyFileIntegrity", itemID)
+	ok, _ := v.(bool)
+	return ok, err
+}
+
+func (m *MockClient) ListComments(ctx context.Context, itemID string) ([]onedrive.Comment, error) {
+	v, err := m.get("ListComments", itemID)
+	comments, _ := v.([]onedrive.Comment)
+	return comments, err
+}
+
+// AddComment uses the item ID as key.
+func (m *MockClient) AddComment(ctx context.Context, itemID, text string) (onedrive.Comment, error) {
+	v, err := m.get("AddComment", itemID)
+	comment, _ := v.(onedrive.Comment)
+	return comment, err
+}
+
+// DeleteComment uses the item ID and comment ID joined by a slash as key.
+func (m *MockClient) DeleteComment(ctx context.Context, itemID, commentID string) error {
+	_, err := m.get("DeleteComment", itemID+"/"+commentID)
+	return err
+}
+
+func (m *MockClient) CreateSharingLink(ctx context.Context, itemID string, opts onedrive.CreateSharingLinkOptions) (onedrive.Permission, error) {
+	v, err := m.get("CreateSharingLink", itemID)
+	permission, _ := v.(onedrive.Permission)
+	return permission, err
+}
+
+// GetItemSharingURL uses the item ID and link type joined by a slash as key.
+func (m *MockClient) GetItemSharingURL(ctx context.Context, item onedrive.DriveItem, linkType onedrive.SharingLinkType) (string, error) {
+	v, err := m.get("GetItemSharingURL", item.Id+"/"+string(linkType))
+	sharingURL, _ := v.(string)
+	return sharingURL, err
+}
+
+// ResolveRemoteItem uses the ID of the remote item as key and returns item if it is not remote.
+func (m *MockClient) ResolveRemoteItem(ctx context.Context, item onedrive.DriveItem) (onedrive.DriveItem, error) {
+	if item.RemoteItem.Id == "" {
+		return item, nil
+	}
+	v, err := m.get("ResolveRemoteItem", item.RemoteItem.Id)
+	resolved, _ := v.(onedrive.DriveItem)
+	return resolved, err
+}
+
+// GetSharePointListItem uses the site, list, and list item IDs joined by slashes as key.
+func (m *MockClient) GetSharePointListItem(ctx context.Context, siteID, listID, listItemID string) (onedrive.ListItem, error) {
+	v, err := m.get("GetSharePointListItem", siteID+"/"+listID+"/"+listItemID)
+	item, _ := v.(onedrive.ListItem)
+	return item, err
+}
+
+// UpdateSharePointListItem uses the site, list, and list item IDs joined by slashes as key.
+func (m *MockClient) UpdateSharePointListItem(ctx context.Context, siteID, listID, listItemID string, fields map[string]interface{}) (onedrive.ListItem, error) {
+	v, err := m.get("UpdateSharePointListItem", siteID+"/"+listID+"/"+listItemID)
+	item, _ := v.(onedrive.ListItem)
+	return item, err
+}
+
+// SetSharingLinkPassword uses the item ID and permission ID joined by a slash as key.
+func (m *MockClient) SetSharingLinkPassword(ctx context.Context, itemID, permissionID, password string) (onedrive.Permission, error) {
+	v, err := m.get("SetSharingLinkPassword", itemID+"/"+permissionID)
+	permission, _ := v.(onedrive.Permission)
+	return permission, err
+}
+
+// RemoveSharingLinkPassword uses the item ID and permission ID joined by a slash as key.
+func (m *MockClient) RemoveSharingLinkPassword(ctx context.Context, itemID, permissionID string) (onedrive.Permission, error) {
+	v, err := m.get("RemoveSharingLinkPassword", itemID+"/"+permissionID)
+	permission, _ := v.(onedrive.Permission)
+	return permission, err
+}
+
+// GetItemBySharepointID uses the site, list, and list item IDs joined by slashes as key.
+func (m *MockClient) GetItemBySharepointID(ctx context.Context, siteID, listID, listItemID string) (onedrive.DriveItem, error) {
+	v, err := m.get("GetItemBySharepointID", siteID+"/"+listID+"/"+listItemID)
+	item, _ := v.(onedrive.DriveItem)
+	return item, err
+}
+
+func (m *MockClient) GetSharedLink(ctx context.Context, sharingURL string) (onedrive.DriveItem, error) {
+	v, err := m.get("GetSharedLink", sharingURL)
+	item, _ := v.(onedrive.DriveItem)
+	return item, err
+}
+
+func (m *MockClient) GetItemByWebURL(ctx context.Context, webURL string) (onedrive.DriveItem, error) {
+	v, err := m.get("GetItemByWebURL", webURL)
+	item, _ := v.(onedrive.DriveItem)
+	return item, err
+}
+
+func (m *MockClient) ListPermissions(ctx context.Context, itemID string) ([]onedrive.Permission, error) {
+	v, err := m.get("ListPermissions", itemID)
+	permissions, _ := v.([]onedrive.Permission)
+	return permissions, err
+}
+
+// GetPermission uses the item ID and permission ID joined by a slash as key.
+func (m *MockClient) GetPermission(ctx context.Context, itemID, permissionID string) (onedrive.Permission, error) {
+	v, err := m.get("GetPermission", itemID+"/"+permissionID)
+	permission, _ := v.(onedrive.Permission)
+	return permission, err
+}
+
+// UpdatePermission uses the item ID and permission ID joined by a slash as key.
+func (m *MockClient) UpdatePermission(ctx context.Context, itemID, permissionID string, roles []string) (onedrive.Permission, error) {
+	v, err := m.get("UpdatePermission", itemID+"/"+permissionID)
+	permission, _ := v.(onedrive.Permission)
+	return permission, err
+}
+
+// RevokeGrants uses the item ID and permission ID joined by a slash as key.
+func (m *MockClient) RevokeGrants(ctx context.Context, itemID, permissionID string, grantees []onedrive.DriveRecipient) (onedrive.Permission, error) {
+	v, err := m.get("RevokeGrants", itemID+"/"+permissionID)
+	permission, _ := v.(onedrive.Permission)
+	return permission, err
+}
+
+func (m *MockClient) GetItemPreview(ctx context.Context, itemID string, zoom float64, page *int, allowEdit bool) (onedrive.PreviewInfo, error) {
+	v, err := m.get("GetItemPreview", itemID)
+	info, _ := v.(onedrive.PreviewInfo)
+	return info, err
+}
+
+func (m *MockClient) PollOperation(ctx context.Context, monitorURL string) (onedrive.DriveItem, error) {
+	v, err := m.get("PollOperation", monitorURL)
+	item, _ := v.(onedrive.DriveItem)
+	return item, err
+}
+
+func (m *MockClient) GetPublicationInfo(ctx context.Context, itemID string) (onedrive.PublicationFacet, error) {
+	v, err := m.get("GetPublicationInfo", itemID)
+	info, _ := v.(onedrive.PublicationFacet)
+	return info, err
+}
+
+func (m *MockClient) Publish(ctx context.Context, itemID string) error {
+	_, err := m.get("Publish", itemID)
+	return err
+}
+
+// CreateSubscription uses the resource of sub as key.
+func (m *MockClient) CreateSubscription(ctx context.Context, sub onedrive.Subscription) (onedrive.Subscription, error) {
+	v, err := m.get("CreateSubscription", sub.Resource)
+	subscription, _ := v.(onedrive.Subscription)
+	return subscription, err
+}
+
+func (m *MockClient) GetSubscription(ctx context.Context, subscriptionID string) (onedrive.Subscription, error) {
+	v, err := m.get("GetSubscription", subscriptionID)
+	subscription, _ := v.(onedrive.Subscription)
+	return subscription, err
+}
+
+func (m *MockClient) RenewSubscription(ctx context.Context, subscriptionID string, newExpiry time.Time) (onedrive.Subscription, error) {
+	v, err := m.get("RenewSubscription", subscriptionID)
+	subscription, _ := v.(onedrive.Subscription)
+	return subscription, err
+}
+
+// TokenExpiry returns the time.Time set for an empty key, or the zero time.
+func (m *MockClient) TokenExpiry() time.Time {
+	v, _ := m.get("TokenExpiry", "")
+	expiry, _ := v.(time.Time)
+	return expiry
+}
+
+func (m *MockClient) GetAccessToken(ctx context.Context) (string, error) {
+	v, err := m.get("GetAccessToken", "")
+	token, _ := v.(string)
+	return token, err
+}
+
+func (m *MockClient) IsTokenValid(ctx context.Context) (bool, error) {
+	v, err := m.get("IsTokenValid", "")
+	ok, _ := v.(bool)
+	return ok, err
+}
@@ -0,0 +1,546 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrivetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bnixon67/onedrive"
+	"golang.org/x/oauth2"
+)
+
+// TestToken is the access token accepted by a TestServer.
+const TestToken = "onedrivetest-token"
+
+// RootID is the ID of the root folder of the drive of a TestServer.
+const RootID = "root"
+
+// TestServer is a fake Graph API server backed by an in-memory drive.
+// It serves the drive as both /me/drive and /drives/{id}, under /v1.0 and /beta.
+type TestServer struct {
+	*httptest.Server
+
+	mu             sync.Mutex
+	drive          onedrive.Drive
+	items          map[string]onedrive.DriveItem
+	order          []string // item IDs in the order they were added
+	content        map[string][]byte
+	nextID         int
+	requests       int
+	rateLimitAfter int
+	pageSize       int
+	tempDirs       []string
+}
+
+// NewTestServer starts a TestServer with an empty drive. Call Close when done.
+func NewTestServer() *TestServer {
+	s := &TestServer{
+		drive: onedrive.Drive{
+			Id:        "onedrivetest-drive",
+			DriveType: "personal",
+			Name:      "OneDrive",
+		},
+		items:   make(map[string]onedrive.DriveItem),
+		content: make(map[string][]byte),
+	}
+	s.addItem(onedrive.DriveItem{
+		Id:     RootID,
+		Name:   "root",
+		Root:   &onedrive.Root{},
+		Folder: &onedrive.Folder{},
+	})
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// Close shuts down the server and removes the files of its test clients.
+func (s *TestServer) Close() {
+	s.Server.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, dir := range s.tempDirs {
+		os.RemoveAll(dir)
+	}
+}
+
+// NewTestClient creates a OneDriveClient that sends its requests to server
+// and authenticates with TestToken. It panics on failure.
+func NewTestClient(server *TestServer, opts ...onedrive.Option) *onedrive.OneDriveClient {
+	dir, err := os.MkdirTemp("", "onedrivetest-*")
+	if err != nil {
+		panic(err)
+	}
+	server.mu.Lock()
+	server.tempDirs = append(server.tempDirs, dir)
+	server.mu.Unlock()
+
+	// a token that does not need to be refreshed
+	token := &oauth2.Token{
+		AccessToken: TestToken,
+		TokenType:   "Bearer",
+		Expiry:      time.Now().Add(24 * time.Hour),
+	}
+	tokenFile := filepath.Join(dir, "token.json")
+	err = onedrive.WriteTokenToFile(tokenFile, token)
+	if err != nil {
+		panic(err)
+	}
+
+	opts = append([]onedrive.Option{onedrive.WithBaseURL(server.URL)}, opts...)
+	client, err := onedrive.NewWithConfig(onedrive.Config{TokenFile: tokenFile}, opts...)
+	if err != nil {
+		panic(err)
+	}
+
+	return client
+}
+
+// AddDriveItem adds item to the drive, replacing any item with the same Id.
+// An item without Id is assigned one, and an item without ParentReference is
+// added to the root folder. The item is returned as stored.
+func (s *TestServer) AddDriveItem(item onedrive.DriveItem) onedrive.DriveItem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addItem(item)
+}
+
+// AddFile adds a file named name with content to the folder identified by parentID.
+func (s *TestServer) AddFile(parentID, name string, content []byte) onedrive.DriveItem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item := s.addItem(onedrive.DriveItem{
+		Name:            name,
+		Size:            int64(len(content)),
+		File:            &onedrive.File{},
+		ParentReference: &onedrive.ParentReference{Id: parentID},
+	})
+	s.content[item.Id] = content
+
+	return item
+}
+
+// SetQuota sets the quota of the drive.
+func (s *TestServer) SetQuota(quota onedrive.Quota) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.drive.Quota = &quota
+}
+
+// SimulateRateLimit makes the server respond with 429 Too Many Requests
+// to every request after the next afterN requests. Zero or less disables it.
+func (s *TestServer) SimulateRateLimit(afterN int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = 0
+	s.rateLimitAfter = afterN
+}
+
+// SetPageSize makes the server return collections, such as children and delta,
+// in pages of n items linked by @odata.nextLink. Zero or less returns one page.
+func (s *TestServer) SetPageSize(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pageSize = n
+}
+
+// page returns the page of items requested by r and the link to the next page,
+// which is empty for the last page. s.mu must be held.
+func (s *TestServer) page(r *http.Request, items []onedrive.DriveItem) ([]onedrive.DriveItem, string) {
+	if s.pageSize <= 0 {
+		return items, ""
+	}
+
+	start, _ := strconv.Atoi(r.URL.Query().Get("$skiptoken"))
+	if start < 0 || start > len(items) {
+		start = len(items)
+	}
+	end := start + s.pageSize
+	if end >= len(items) {
+		return items[start:], ""
+	}
+
+	return items[start:end], fmt.Sprintf("%s%s?$skiptoken=%d", s.URL, r.URL.Path, end)
+}
+
+// addItem stores item, filling in its Id, ParentReference, ETag, and timestamps.
+// s.mu must be held.
+func (s *TestServer) addItem(item onedrive.DriveItem) onedrive.DriveItem {
+	if item.Id == "" {
+		s.nextID++
+		item.Id = fmt.Sprintf("item-%d", s.nextID)
+	}
+	if item.Root == nil {
+		if item.ParentReference == nil {
+			item.ParentReference = &onedrive.ParentReference{Id: RootID}
+		}
+		ref := *item.ParentReference
+		ref.DriveId = s.drive.Id
+		ref.DriveType = s.drive.DriveType
+		ref.Path = s.pathOf(ref.Id)
+		item.ParentReference = &ref
+	}
+	if item.ETag == "" {
+		s.nextID++
+		item.ETag = fmt.Sprintf("etag-%d", s.nextID)
+	}
+	if item.LastModifiedDateTime == "" {
+		item.LastModifiedDateTime = now()
+	}
+	if item.CreatedDateTime == "" {
+		item.CreatedDateTime = item.LastModifiedDateTime
+	}
+
+	if _, ok := s.items[item.Id]; !ok {
+		s.order = append(s.order, item.Id)
+	}
+	s.items[item.Id] = item
+
+	return item
+}
+
+// now returns the current time as a Graph timestamp.
+func now() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+// pathOf returns the parentReference path of the children of the folder identified by id.
+func (s *TestServer) pathOf(id string) string {
+	var names []string
+	for id != RootID {
+		item, ok := s.items[id]
+		if !ok || item.ParentReference == nil {
+			break
+		}
+		names = append([]string{item.Name}, names...)
+		id = item.ParentReference.Id
+	}
+	if len(names) == 0 {
+		return "/drive/root:"
+	}
+
+	return "/drive/root:/" + strings.Join(names, "/")
+}
+
+// children returns the children of the folder identified by id in the order they were added.
+func (s *TestServer) children(id string) []onedrive.DriveItem {
+	children := []onedrive.DriveItem{}
+	for _, childID := range s.order {
+		child, ok := s.items[childID]
+		if ok && child.ParentReference != nil && child.ParentReference.Id == id {
+			children = append(children, child)
+		}
+	}
+
+	return children
+}
+
+// within reports whether the item identified by id is the folder identified by folderID
+// or one of its descendants.
+func (s *TestServer) within(id, folderID string) bool {
+	for {
+		if id == folderID {
+			return true
+		}
+		item, ok := s.items[id]
+		if !ok || item.ParentReference == nil {
+			return false
+		}
+		id = item.ParentReference.Id
+	}
+}
+
+// itemByPath returns the item at path relative to the root folder.
+func (s *TestServer) itemByPath(path string) (onedrive.DriveItem, bool) {
+	item := s.items[RootID]
+	for _, name := range strings.Split(strings.Trim(path, "/"), "/") {
+		if name == "" {
+			continue
+		}
+		found := false
+		for _, child := range s.children(item.Id) {
+			if strings.EqualFold(child.Name, name) {
+				item, found = child, true
+				break
+			}
+		}
+		if !found {
+			return onedrive.DriveItem{}, false
+		}
+	}
+
+	return item, true
+}
+
+// writeJSON writes v as a JSON response with status.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes a Graph error response.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, onedrive.RespError{Err: &onedrive.Err{
+		Code:    code,
+		Message: message,
+		InnerError: &onedrive.InnerError{
+			RequestId: "onedrivetest-request",
+			Date:      time.Now().UTC().Format(time.RFC3339),
+		},
+	}})
+}
+
+// serveHTTP serves the Graph API requests that the test server supports.
+func (s *TestServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+	if s.rateLimitAfter > 0 && s.requests > s.rateLimitAfter {
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusTooManyRequests, "activityLimitReached", "too many requests")
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer "+TestToken {
+		writeError(w, http.StatusUnauthorized, "InvalidAuthenticationToken", "access token is invalid")
+		return
+	}
+
+	path := r.URL.Path
+	switch {
+	case strings.HasPrefix(path, "/v1.0/"):
+		path = strings.TrimPrefix(path, "/v1.0")
+	case strings.HasPrefix(path, "/beta/"):
+		path = strings.TrimPrefix(path, "/beta")
+	default:
+		writeError(w, http.StatusBadRequest, "BadRequest", "unsupported API version")
+		return
+	}
+
+	switch path {
+	case "/me":
+		writeJSON(w, http.StatusOK, onedrive.User{Id: "onedrivetest-user", DisplayName: "Test User"})
+		return
+	case "/me/drives":
+		writeJSON(w, http.StatusOK, onedrive.Drives{Value: []onedrive.Drive{s.drive}})
+		return
+	}
+
+	// strip the drive prefix
+	switch {
+	case path == "/me/drive" || strings.HasPrefix(path, "/me/drive/"):
+		path = strings.TrimPrefix(path, "/me/drive")
+	case strings.HasPrefix(path, "/drives/"+s.drive.Id):
+		path = strings.TrimPrefix(path, "/drives/"+s.drive.Id)
+	default:
+		writeError(w, http.StatusNotFound, "itemNotFound", "resource not found")
+		return
+	}
+
+	if path == "" {
+		writeJSON(w, http.StatusOK, s.drive)
+		return
+	}
+
+	if path == "/recent" {
+		files := []onedrive.DriveItem{}
+		for _, id := range s.order {
+			if item := s.items[id]; item.File != nil {
+				files = append(files, item)
+			}
+		}
+		files, next := s.page(r, files)
+		writeJSON(w, http.StatusOK, onedrive.DriveItems{Value: files, NextLink: next})
+		return
+	}
+
+	// resolve the item addressed by /items/{id}, /root, or /root:/path:
+	var (
+		item   onedrive.DriveItem
+		ok     bool
+		action string
+	)
+	switch {
+	case strings.HasPrefix(path, "/items/"):
+		rest := strings.TrimPrefix(path, "/items/")
+		id := rest
+		if i := strings.Index(rest, "/"); i >= 0 {
+			id, action = rest[:i], rest[i+1:]
+		}
+		item, ok = s.items[id]
+	case strings.HasPrefix(path, "/root:"):
+		rest := strings.TrimPrefix(path, "/root:")
+		itemPath := rest
+		if i := strings.Index(rest, ":"); i >= 0 {
+			itemPath, action = rest[:i], strings.TrimPrefix(rest[i+1:], "/")
+		}
+		item, ok = s.itemByPath(itemPath)
+	case path == "/root" || strings.HasPrefix(path, "/root/"):
+		item, ok = s.items[RootID]
+		action = strings.TrimPrefix(strings.TrimPrefix(path, "/root"), "/")
+	}
+	if !ok && r.Method == http.MethodPut && action == "content" && strings.HasPrefix(path, "/root:") {
+		// uploading a new file to an existing folder
+		itemPath := strings.TrimSuffix(strings.TrimPrefix(path, "/root:"), ":/content")
+		i := strings.LastIndex(itemPath, "/")
+		if parent, found := s.itemByPath(itemPath[:i]); found && parent.Folder != nil {
+			item = s.addItem(onedrive.DriveItem{
+				Name:            itemPath[i+1:],
+				File:            &onedrive.File{},
+				ParentReference: &onedrive.ParentReference{Id: parent.Id},
+			})
+			ok = true
+		}
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "itemNotFound", "item not found")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && action == "":
+		writeJSON(w, http.StatusOK, item)
+	case r.Method == http.MethodGet && action == "children":
+		children, next := s.page(r, s.children(item.Id))
+		writeJSON(w, http.StatusOK, onedrive.DriveItems{Value: children, NextLink: next})
+	case r.Method == http.MethodGet && action == "content":
+		content, ok := s.content[item.Id]
+		if !ok {
+			writeError(w, http.StatusNotFound, "itemNotFound", "item has no content")
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(content)
+	case r.Method == http.MethodPut && action == "content":
+		content, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalidRequest", err.Error())
+			return
+		}
+		s.content[item.Id] = content
+		item.Size = int64(len(content))
+		item.File = &onedrive.File{MimeType: r.Header.Get("Content-Type")}
+		s.nextID++
+		item.ETag = fmt.Sprintf("etag-%d", s.nextID)
+		item.LastModifiedDateTime = now()
+		s.items[item.Id] = item
+		writeJSON(w, http.StatusCreated, item)
+	case r.Method == http.MethodGet && action == "delta":
+		var items []onedrive.DriveItem
+		for _, id := range s.order {
+			if s.within(id, item.Id) {
+				items = append(items, s.items[id])
+			}
+		}
+		page := onedrive.DeltaItems{}
+		page.Value, page.NextLink = s.page(r, items)
+		if page.NextLink == "" {
+			page.DeltaLink = s.URL + r.URL.Path + "?token=latest"
+		}
+		writeJSON(w, http.StatusOK, page)
+	case r.Method == http.MethodPost && action == "children":
+		s.createChild(w, r, item)
+	case r.Method == http.MethodPatch && action == "":
+		s.updateItem(w, r, item)
+	case r.Method == http.MethodDelete && action == "":
+		delete(s.items, item.Id)
+		delete(s.content, item.Id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusNotImplemented, "notSupported", "not supported by the test server")
+	}
+}
+
+// updateItem applies the name, parent, and file system info in the request body to item.
+func (s *TestServer) updateItem(w http.ResponseWriter, r *http.Request, item onedrive.DriveItem) {
+	var in struct {
+		Name            string                    `json:"name"`
+		ParentReference *onedrive.ParentReference `json:"parentReference"`
+		FileSystemInfo  *onedrive.FileSystemInfo  `json:"fileSystemInfo"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, "invalidRequest", err.Error())
+		return
+	}
+
+	if in.Name != "" {
+		item.Name = in.Name
+	}
+	if in.ParentReference != nil && in.ParentReference.Id != "" {
+		parent, ok := s.items[in.ParentReference.Id]
+		if !ok || (parent.Folder == nil && parent.Root == nil) {
+			writeError(w, http.StatusNotFound, "itemNotFound", "parent not found")
+			return
+		}
+		item.ParentReference = &onedrive.ParentReference{Id: parent.Id}
+	}
+	if in.FileSystemInfo != nil {
+		item.FileSystemInfo = *in.FileSystemInfo
+	}
+	item.LastModifiedDateTime = now()
+	s.nextID++
+	item.ETag = fmt.Sprintf("etag-%d", s.nextID)
+
+	writeJSON(w, http.StatusOK, s.addItem(item))
+}
+
+// createChild creates the folder described by the request body in parent.
+func (s *TestServer) createChild(w http.ResponseWriter, r *http.Request, parent onedrive.DriveItem) {
+	var in struct {
+		Name   string           `json:"name"`
+		Folder *onedrive.Folder `json:"folder"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&in)
+	if err != nil || in.Name == "" {
+		writeError(w, http.StatusBadRequest, "invalidRequest", "invalid request body")
+		return
+	}
+
+	for _, child := range s.children(parent.Id) {
+		if strings.EqualFold(child.Name, in.Name) {
+			writeError(w, http.StatusConflict, "nameAlreadyExists", "name already exists")
+			return
+		}
+	}
+
+	item := s.addItem(onedrive.DriveItem{
+		Name:            in.Name,
+		Folder:          &onedrive.Folder{},
+		ParentReference: &onedrive.ParentReference{Id: parent.Id},
+	})
+	writeJSON(w, http.StatusCreated, item)
+}
@@ -0,0 +1,417 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Option configures a OneDriveClient when it is created.
+type Option func(*options)
+
+// options holds the settings applied by Option values.
+type options struct {
+	transport TransportConfig
+	timeout   time.Duration
+
+	http2             *bool
+	keepAlive         time.Duration
+	disableKeepAlives bool
+
+	metrics   Metrics
+	requestID string
+	breaker   *circuitBreaker
+
+	downloadConcurrency int
+	verifyOnDownload    bool
+
+	graphHost    string
+	graphVersion GraphVersion
+	cloud        NationalCloud
+
+	anchorMailbox string
+
+	cache *itemCache
+
+	pollInterval    time.Duration
+	maxPollInterval time.Duration
+
+	limiter *rate.Limiter
+
+	detectContentType func(name string, head []byte) string
+
+	beforeRequest []func(req *http.Request) error
+	afterResponse []func(req *http.Request, resp *http.Response, err error)
+}
+
+// defaultTimeout is the default limit for a single request/response cycle.
+const defaultTimeout = 30 * time.Second
+
+// newOptions returns the options that result from applying opts.
+func newOptions(opts []Option) *options {
+	o := &options{
+		timeout: defaultTimeout,
+		metrics: NoopMetrics{},
+
+		downloadConcurrency: defaultDownloadConcurrency,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// TransportConfig tunes the connection pool of the HTTP transport.
+// A zero value for a field keeps the default of http.DefaultTransport.
+type TransportConfig struct {
+	// Maximum number of idle connections across all hosts.
+	MaxIdleConns int
+
+	// Maximum number of idle connections to keep per host.
+	MaxIdleConnsPerHost int
+
+	// Maximum amount of time an idle connection remains idle before closing.
+	IdleConnTimeout time.Duration
+
+	// Maximum amount of time to wait for a TLS handshake.
+	TLSHandshakeTimeout time.Duration
+}
+
+// WithTransportConfig configures the connection pool of the HTTP transport
+// that carries Graph requests. The oauth2 transport wraps this transport,
+// so the settings also apply to token requests.
+func WithTransportConfig(tc TransportConfig) Option {
+	return func(o *options) {
+		o.transport = tc
+	}
+}
+
+// roundTripper returns the base transport, limited by the rate limiter if set.
+func (o *options) roundTripper() http.RoundTripper {
+	t := o.baseTransport()
+	if o.limiter == nil {
+		return t
+	}
+
+	return &rateLimitTransport{base: t, limiter: o.limiter}
+}
+
+// baseTransport returns the transport wrapped by the oauth2 transport.
+func (o *options) baseTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	tc := o.transport
+	if tc.MaxIdleConns != 0 {
+		t.MaxIdleConns = tc.MaxIdleConns
+	}
+	if tc.MaxIdleConnsPerHost != 0 {
+		t.MaxIdleConnsPerHost = tc.MaxIdleConnsPerHost
+	}
+	if tc.IdleConnTimeout != 0 {
+		t.IdleConnTimeout = tc.IdleConnTimeout
+	}
+	if tc.TLSHandshakeTimeout != 0 {
+		t.TLSHandshakeTimeout = tc.TLSHandshakeTimeout
+	}
+
+	if o.http2 != nil && !*o.http2 {
+		// a non-nil empty map disables the automatic HTTP/2 upgrade
+		t.ForceAttemptHTTP2 = false
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	} else {
+		t.ForceAttemptHTTP2 = true
+	}
+	if o.keepAlive != 0 {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: o.keepAlive}
+		t.DialContext = dialer.DialContext
+	}
+	t.DisableKeepAlives = o.disableKeepAlives
+
+	// also bounds requests that transfer content, which have no overall timeout
+	if o.timeout > 0 {
+		t.ResponseHeaderTimeout = o.timeout
+	}
+
+	return t
+}
+
+// WithHTTP2 controls whether requests use HTTP/2 when the server supports it, which
+// multiplexes concurrent requests, such as parallel uploads and downloads, over a single
+// connection per host. HTTP/2 is enabled by default. Like WithTransportConfig, it also
+// applies to token requests, since the oauth2 transport wraps the same transport.
+func WithHTTP2(enabled bool) Option {
+	return func(o *options) {
+		o.http2 = &enabled
+	}
+}
+
+// WithKeepAlive sets the interval between TCP keep-alive probes of the connections
+// of the transport, which detect dead connections, rather than the default of 30 seconds.
+// A negative d disables the probes.
+func WithKeepAlive(d time.Duration) Option {
+	return func(o *options) {
+		o.keepAlive = d
+	}
+}
+
+// WithDisableKeepAlives, if disable is true, closes each connection after a single request
+// instead of reusing it. Token refreshes share the transport, so each refresh then also
+// opens a new connection to the token endpoint, which adds latency to the request that
+// triggers the refresh but does not otherwise affect authentication.
+func WithDisableKeepAlives(disable bool) Option {
+	return func(o *options) {
+		o.disableKeepAlives = disable
+	}
+}
+
+// WithTimeout limits the time of each individual HTTP request/response cycle,
+// including reading the response body. Requests that transfer file content,
+// such as DownloadFile, UploadSmallFile, and the chunks of UploadLargeFile, can take
+// much longer, so for them the timeout only limits the wait for the response headers.
+// The default is 30 seconds and zero means no timeout.
+//
+// The timeout is independent of any deadline on the context passed to a method.
+// A context deadline bounds the entire operation, which may consist of several
+// HTTP requests, such as paging through results or refreshing the token.
+// The per-request timeout bounds each of those requests individually.
+// Whichever expires first cancels the request in progress. Use a context deadline
+// to bound the transfer of file content.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// WithMetrics records the calls made by the client with m.
+// The default, NoopMetrics, discards them.
+func WithMetrics(m Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+// SetRequestIDHeader sends id as the client-request-id header of every request,
+// so the requests of the client can be traced end-to-end in Graph logs.
+func SetRequestIDHeader(id string) Option {
+	return func(o *options) {
+		o.requestID = id
+	}
+}
+
+// WithCircuitBreaker stops making requests after threshold consecutive failures,
+// returning ErrCircuitOpen immediately instead. Failures are transport errors
+// and 429 or 5xx responses; requests that fail because their context is done,
+// e.g., cancelled by the caller, are not counted. After resetAfter, one probe request is allowed;
+// if it succeeds requests resume, otherwise the breaker stays open for another resetAfter.
+// A threshold less than 1 disables the circuit breaker.
+func WithCircuitBreaker(threshold int, resetAfter time.Duration) Option {
+	return func(o *options) {
+		if threshold < 1 {
+			o.breaker = nil
+			return
+		}
+		o.breaker = &circuitBreaker{threshold: threshold, resetAfter: resetAfter}
+	}
+}
+
+// defaultDownloadConcurrency is the default number of concurrent downloads.
+const defaultDownloadConcurrency = 4
+
+// WithDownloadConcurrency limits the number of files downloaded concurrently
+// by methods that download several files, such as DownloadAsZip. The default is 4.
+func WithDownloadConcurrency(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.downloadConcurrency = n
+		}
+	}
+}
+
+// WithVerifyOnDownload, if verify is true, verifies the content downloaded by DownloadFile,
+// and the methods that use it, such as DownloadAsZip, against the quickXorHash of the file,
+// or if not available, its sha1Hash or sha256Hash. The hash is computed while the content
+// is written, without reading it again, at the cost of a metadata request per file.
+// A mismatch returns an IntegrityError that matches ErrIntegrityCheckFailed.
+func WithVerifyOnDownload(verify bool) Option {
+	return func(o *options) {
+		o.verifyOnDownload = verify
+	}
+}
+
+// WithNationalCloud authenticates with and sends Graph requests to the
+// endpoints of cloud instead of the global service.
+func WithNationalCloud(cloud NationalCloud) Option {
+	return func(o *options) {
+		o.cloud = cloud
+	}
+}
+
+// WithBaseURL sends Graph requests to baseURL, e.g., http://127.0.0.1:8080,
+// instead of the Graph endpoint of the cloud, such as to use a test server.
+// The API version, e.g., /v1.0, is appended to baseURL. Authentication is not affected.
+func WithBaseURL(baseURL string) Option {
+	return func(o *options) {
+		o.graphHost = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// GraphBaseURL is the scheme and host of the Graph API used by clients of the
+// global service that are created without WithBaseURL. The API version, e.g., /v1.0,
+// is appended. It is read when a client is created; see SetGraphBaseURL.
+var GraphBaseURL = defaultGraphHost
+
+// SetGraphBaseURL sets GraphBaseURL to baseURL, e.g., http://localhost:8080, so every
+// client created afterwards sends its Graph requests to a mock server, such as in TestMain.
+// It must not be called concurrently with creating clients.
+func SetGraphBaseURL(baseURL string) {
+	GraphBaseURL = strings.TrimSuffix(baseURL, "/")
+}
+
+// WithCache caches the DriveItem metadata read by GetItemByID and GetItemByPath
+// in cache for ttl, e.g., using NewLRUCache. Any write made by the client discards
+// the cached items; changes made by others are seen once the items expire.
+// The ContentURL of a cached item may have expired without being detected,
+// so keep ttl short if ContentURL is used.
+func WithCache(cache Cache, ttl time.Duration) Option {
+	return func(o *options) {
+		if cache != nil && ttl > 0 {
+			o.cache = &itemCache{cache: cache, ttl: ttl}
+		}
+	}
+}
+
+// GraphVersion is a version of the Graph API.
+type GraphVersion string
+
+const (
+	// GraphV1 is the generally available version of the Graph API.
+	GraphV1 GraphVersion = "v1.0"
+
+	// GraphBeta is the preview version of the Graph API.
+	// Beta APIs can change without notice and are not supported in production.
+	GraphBeta GraphVersion = "beta"
+)
+
+// WithGraphVersion sends Graph requests to version v of the API instead of GraphV1.
+// Features that only exist in beta, such as GetDriveActivities, use beta regardless.
+func WithGraphVersion(v GraphVersion) Option {
+	return func(o *options) {
+		o.graphVersion = v
+	}
+}
+
+// WithAnchorMailbox sends upn, the user principal name of the user being acted on,
+// as the X-AnchorMailbox header of every Graph request, so Graph routes the requests
+// to the datacenter of the user. Only applies to app-only authentication.
+func WithAnchorMailbox(upn string) Option {
+	return func(o *options) {
+		o.anchorMailbox = upn
+	}
+}
+
+// WithPollInterval sets the first interval between the status polls of PollOperation,
+// which doubles after every poll up to maxInterval.
+func WithPollInterval(interval, maxInterval time.Duration) Option {
+	return func(o *options) {
+		o.pollInterval = interval
+		o.maxPollInterval = maxInterval
+	}
+}
+
+// WithContentTypeDetector sets the function that determines the Content-Type of
+// content uploaded by UploadSmallFile without a content type, given the name of
+// the file and up to the first 512 bytes of the content. The default is DetectContentType.
+func WithContentTypeDetector(detect func(name string, head []byte) string) Option {
+	return func(o *options) {
+		o.detectContentType = detect
+	}
+}
+
+// WithBeforeRequest adds hook to the hooks called before every request is sent,
+// see Config.BeforeRequest. Hooks run in the order they are added and the first
+// error aborts the request.
+func WithBeforeRequest(hook func(req *http.Request) error) Option {
+	return func(o *options) {
+		if hook != nil {
+			o.beforeRequest = append(o.beforeRequest, hook)
+		}
+	}
+}
+
+// WithAfterResponse adds hook to the hooks called after every request,
+// see Config.AfterResponse. Hooks run in the order they are added.
+func WithAfterResponse(hook func(req *http.Request, resp *http.Response, err error)) Option {
+	return func(o *options) {
+		if hook != nil {
+			o.afterResponse = append(o.afterResponse, hook)
+		}
+	}
+}
+
+// RequestOption modifies a single outgoing request.
+type RequestOption func(req *http.Request)
+
+// requestOptionsKey is the context key for request options.
+type requestOptionsKey struct{}
+
+// WithRequestOptions returns a copy of ctx that carries opts. The options are
+// applied, after any options already carried by ctx, to every request made
+// by a method called with the returned context.
+func WithRequestOptions(ctx context.Context, opts ...RequestOption) context.Context {
+	prev, _ := ctx.Value(requestOptionsKey{}).([]RequestOption)
+
+	all := make([]RequestOption, 0, len(prev)+len(opts))
+	all = append(all, prev...)
+	all = append(all, opts...)
+
+	return context.WithValue(ctx, requestOptionsKey{}, all)
+}
+
+// applyRequestOptions applies the request options carried by the context of req.
+func applyRequestOptions(req *http.Request) {
+	opts, _ := req.Context().Value(requestOptionsKey{}).([]RequestOption)
+	for _, opt := range opts {
+		opt(req)
+	}
+}
+
+// WithCorrelationID sends id as the client-request-id header of the request,
+// overriding SetRequestIDHeader, so calls that are part of a single logical
+// operation can be correlated. See NewCorrelationID and GetCorrelationID.
+func WithCorrelationID(id string) RequestOption {
+	return func(req *http.Request) {
+		req.Header.Set("client-request-id", id)
+	}
+}
+
+// WithPreferRepresentation asks Graph to return the created or updated resource
+// instead of 204 No Content, so methods that return a DriveItem, such as
+// CreateFolder and RestoreFromRecycleBin, return the full item without another request.
+func WithPreferRepresentation() RequestOption {
+	return func(req *http.Request) {
+		req.Header.Add("Prefer", "return=representation")
+	}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestWithTransportConfig(t *testing.T) {
+	tc := TransportConfig{
+		MaxIdleConns:        500,
+		MaxIdleConnsPerHost: 50,
+		IdleConnTimeout:     2 * time.Minute,
+		TLSHandshakeTimeout: 3 * time.Second,
+	}
+	token := &oauth2.Token{AccessToken: "token", Expiry: time.Now().Add(time.Hour)}
+	client := NewFromOAuthConfig(context.Background(), &oauth2.Config{}, token, WithTransportConfig(tc))
+
+	// the oauth2 transport must wrap the configured transport instead of replacing it
+	ot, ok := client.httpClient.Transport.(*oauth2.Transport)
+	if !ok {
+		t.Fatalf("httpClient.Transport = %T, want *oauth2.Transport", client.httpClient.Transport)
+	}
+	transports := map[string]http.RoundTripper{
+		"oauth2 base":   ot.Base,
+		"upload client": client.uploadClient.Transport,
+	}
+
+	for name, rt := range transports {
+		t.Run(name, func(t *testing.T) {
+			got, ok := rt.(*http.Transport)
+			if !ok {
+				t.Fatalf("transport = %T, want *http.Transport", rt)
+			}
+			if got.MaxIdleConns != tc.MaxIdleConns {
+				t.Errorf("MaxIdleConns = %d, want %d", got.MaxIdleConns, tc.MaxIdleConns)
+			}
+			if got.MaxIdleConnsPerHost != tc.MaxIdleConnsPerHost {
+				t.Errorf("MaxIdleConnsPerHost = %d, want %d", got.MaxIdleConnsPerHost, tc.MaxIdleConnsPerHost)
+			}
+			if got.IdleConnTimeout != tc.IdleConnTimeout {
+				t.Errorf("IdleConnTimeout = %v, want %v", got.IdleConnTimeout, tc.IdleConnTimeout)
+			}
+			if got.TLSHandshakeTimeout != tc.TLSHandshakeTimeout {
+				t.Errorf("TLSHandshakeTimeout = %v, want %v", got.TLSHandshakeTimeout, tc.TLSHandshakeTimeout)
+			}
+		})
+	}
+}
+
+func TestTransportConfigDefaults(t *testing.T) {
+	def := http.DefaultTransport.(*http.Transport)
+	got := newOptions(nil).baseTransport()
+
+	if got == def {
+		t.Fatal("baseTransport returned http.DefaultTransport instead of a clone")
+	}
+	if got.MaxIdleConns != def.MaxIdleConns || got.MaxIdleConnsPerHost != def.MaxIdleConnsPerHost ||
+		got.IdleConnTimeout != def.IdleConnTimeout || got.TLSHandshakeTimeout != def.TLSHandshakeTimeout {
+		t.Errorf("zero TransportConfig changed the defaults of http.DefaultTransport")
+	}
+}
+
+// newTestClient returns a client with opts that sends its requests to handler.
+func newTestClient(t testing.TB, handler http.Handler, opts ...Option) *OneDriveClient {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	token := &oauth2.Token{AccessToken: "token", Expiry: time.Now().Add(time.Hour)}
+	opts = append([]Option{WithBaseURL(server.URL)}, opts...)
+
+	return NewFromOAuthConfig(context.Background(), &oauth2.Config{}, token, opts...)
+}
+
+func TestWithTimeout(t *testing.T) {
+	const timeout = 200 * time.Millisecond
+
+	// slowBody sends the headers at once and the rest of the body after three timeouts
+	slowBody := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":`))
+		w.(http.Flusher).Flush()
+		time.Sleep(3 * timeout)
+		w.Write([]byte(`"1"}`))
+	})
+	// slowHeaders sends nothing for three timeouts
+	slowHeaders := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(3 * timeout)
+		w.Write([]byte(`{"id":"1"}`))
+	})
+
+	download := func(c *OneDriveClient) error {
+		return c.DownloadFile(context.Background(), "1", &bytes.Buffer{})
+	}
+	getItem := func(c *OneDriveClient) error {
+		_, err := c.GetItemByID(context.Background(), "1")
+		return err
+	}
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		call    func(c *OneDriveClient) error
+		wantErr bool
+	}{
+		{"download with slow body", slowBody, download, false},
+		{"download with slow headers", slowHeaders, download, true},
+		{"metadata with slow body", slowBody, getItem, true},
+		{"metadata with slow headers", slowHeaders, getItem, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, tt.handler, WithTimeout(timeout))
+
+			err := tt.call(c)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+)
+
+// PreviewInfo holds the URLs to embed a preview of an item.
+// Either GetURL or PostURL and PostParameters are set.
+type PreviewInfo struct {
+	// URL to embed the preview with a GET request, e.g., in an iframe.
+	GetURL string `json:"getUrl,omitempty"`
+
+	// URL to embed the preview with a form POST request of PostParameters.
+	PostURL string `json:"postUrl,omitempty"`
+
+	// The form body for PostURL, encoded as application/x-www-form-urlencoded.
+	PostParameters string `json:"postParameters,omitempty"`
+}
+
+// GetItemPreview creates short-lived URLs to embed a preview of the item identified
+// by itemID. zoom is the zoom level, or zero for the default; page is the page to
+// start on, if not nil; allowEdit allows editing if the item supports it.
+// The URLs expire after approximately one hour.
+func (c *OneDriveClient) GetItemPreview(ctx context.Context, itemID string, zoom float64, page *int, allowEdit bool) (info PreviewInfo, err error) {
+	in := struct {
+		Zoom      float64 `json:"zoom,omitempty"`
+		Page      *int    `json:"page,omitempty"`
+		AllowEdit bool    `json:"allowEdit,omitempty"`
+	}{zoom, page, allowEdit}
+
+	err = c.doJSON(ctx, http.MethodPost,
+		c.driveURL("/items/"+url.PathEscape(itemID)+"/preview"), in, &info)
+
+	return info, err
+}
+
+// BuildPreviewIframe returns an HTML iframe of width and height pixels that embeds
+// the preview at info.GetURL. An empty string is returned if info has no GetURL,
+// since a PostURL preview must be embedded by posting a form.
+func BuildPreviewIframe(info PreviewInfo, width, height int) string {
+	if info.GetURL == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(`<iframe src="%s" width="%d" height="%d" frameborder="0"></iframe>`,
+		html.EscapeString(info.GetURL), width, height)
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// GetPublicationInfo retrieves the publishing state of the item identified by itemID.
+// Publishing is only supported by OneDrive for Business and SharePoint drives.
+func (c *OneDriveClient) GetPublicationInfo(ctx context.Context, itemID string) (PublicationFacet, error) {
+	body, err := c.get(ctx, c.driveURL("/items/"+url.PathEscape(itemID)+"?$expand=publication"))
+	if err != nil {
+		return PublicationFacet{}, err
+	}
+
+	var item DriveItem
+	err = c.unmarshal(body, &item)
+	if err != nil || item.Publication == nil {
+		return PublicationFacet{}, err
+	}
+
+	return *item.Publication, nil
+}
+
+// Publish checks in the checked out item identified by itemID as a published
+// major version, making it visible to everyone with access to the item.
+func (c *OneDriveClient) Publish(ctx context.Context, itemID string) error {
+	in := struct {
+		CheckInAs string `json:"checkInAs"`
+		Comment   string `json:"comment"`
+	}{CheckInAs: "published"}
+
+	return c.doJSON(ctx, http.MethodPost,
+		c.driveURL("/items/"+url.PathEscape(itemID)+"/checkin"), in, nil)
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"hash"
+	"io"
+	"os"
+)
+
+// quickXorHash computes the 160-bit quickXorHash used by OneDrive for file integrity.
+// See https://docs.microsoft.com/en-us/onedrive/developer/code-snippets/quickxorhash
+type quickXorHash struct {
+	data        [3]uint64 // 160 bits, the last cell only uses 32
+	shiftSoFar  int
+	lengthSoFar int64
+}
+
+const (
+	quickXorWidthInBits = 160
+	quickXorShift       = 11
+	quickXorSize        = (quickXorWidthInBits-1)/8 + 1
+)
+
+// newQuickXorHash returns a hash.Hash computing the quickXorHash.
+func newQuickXorHash() hash.Hash {
+	return &quickXorHash{}
+}
+
+// Write adds p to the hash. It never returns an error.
+func (q *quickXorHash) Write(p []byte) (n int, err error) {
+	currentShift := q.shiftSoFar
+
+	// the bitvector where we'll start xoring
+	vectorArrayIndex := currentShift / 64
+
+	// the position within the bit vector at which we begin xoring
+	vectorOffset := currentShift % 64
+
+	iterations := len(p)
+	if iterations > quickXorWidthInBits {
+		iterations = quickXorWidthInBits
+	}
+
+	for i := 0; i < iterations; i++ {
+		isLastCell := vectorArrayIndex == len(q.data)-1
+		bitsInVectorCell := 64
+		if isLastCell {
+			bitsInVectorCell = quickXorWidthInBits % 64
+		}
+
+		if vectorOffset <= bitsInVectorCell-8 {
+			// the byte fits within the current cell
+			for j := i; j < len(p); j += quickXorWidthInBits {
+				q.data[vectorArrayIndex] ^= uint64(p[j]) << vectorOffset
+			}
+		} else {
+			// the byte spans the current cell and the next one
+			index1 := vectorArrayIndex
+			index2 := vectorArrayIndex + 1
+			if isLastCell {
+				index2 = 0
+			}
+			low := bitsInVectorCell - vectorOffset
+
+			var xoredByte byte
+			for j := i; j < len(p); j += quickXorWidthInBits {
+				xoredByte ^= p[j]
+			}
+			q.data[index1] ^= uint64(xoredByte) << vectorOffset
+			q.data[index2] ^= uint64(xoredByte) >> low
+		}
+
+		vectorOffset += quickXorShift
+		for vectorOffset >= bitsInVectorCell {
+			if isLastCell {
+				vectorArrayIndex = 0
+			} else {
+				vectorArrayIndex++
+			}
+			vectorOffset -= bitsInVectorCell
+		}
+	}
+
+	q.shiftSoFar = (q.shiftSoFar + quickXorShift*(len(p)%quickXorWidthInBits)) % quickXorWidthInBits
+	q.lengthSoFar += int64(len(p))
+
+	return len(p), nil
+}
+
+// Sum appends the current hash to b and returns the resulting slice.
+func (q *quickXorHash) Sum(b []byte) []byte {
+	rgb := make([]byte, 3*8)
+	for i, cell := range q.data {
+		binary.LittleEndian.PutUint64(rgb[i*8:], cell)
+	}
+	rgb = rgb[:quickXorSize]
+
+	// xor the length into the last 64 bits
+	length := make([]byte, 8)
+	binary.LittleEndian.PutUint64(length, uint64(q.lengthSoFar))
+	for i := range length {
+		rgb[quickXorWidthInBits/8-8+i] ^= length[i]
+	}
+
+	return append(b, rgb...)
+}
+
+// Reset resets the hash to its initial state.
+func (q *quickXorHash) Reset() {
+	*q = quickXorHash{}
+}
+
+// Size returns the number of bytes returned by Sum.
+func (q *quickXorHash) Size() int {
+	return quickXorSize
+}
+
+// BlockSize returns the block size of the hash.
+func (q *quickXorHash) BlockSize() int {
+	return 64
+}
+
+// QuickXorHash returns the quickXorHash of the content of r.
+func QuickXorHash(r io.Reader) ([]byte, error) {
+	h := newQuickXorHash()
+
+	_, err := io.Copy(h, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.Sum(nil), nil
+}
+
+// QuickXorHashFile returns the quickXorHash of the file at path,
+// base64 encoded like the quickXorHash of the Hashes facet returned by Graph.
+func QuickXorHashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	sum, err := QuickXorHash(file)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(sum), nil
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// referenceQuickXorHash computes the quickXorHash bit by bit, as defined by Microsoft:
+// bit j of byte i of the content is XORed into bit (11*i + j) mod 160 of the hash,
+// where bit p of the hash is bit p%8 of byte p/8, and the content length is XORed,
+// little-endian, into the last 8 bytes.
+func referenceQuickXorHash(content []byte) []byte {
+	sum := make([]byte, 20)
+	for i, b := range content {
+		for j := 0; j < 8; j++ {
+			if b>>j&1 == 1 {
+				p := (11*i + j) % 160
+				sum[p/8] ^= 1 << (p % 8)
+			}
+		}
+	}
+
+	var length [8]byte
+	binary.LittleEndian.PutUint64(length[:], uint64(len(content)))
+	for i := range length {
+		sum[12+i] ^= length[i]
+	}
+
+	return sum
+}
+
+func TestQuickXorHashVectors(t *testing.T) {
+	tests := []struct {
+		content string // base64
+		want    string // base64
+	}{
+		{"", "AAAAAAAAAAAAAAAAAAAAAAAAAAA="},
+		{"Sg==", "SgAAAAAAAAAAAAAAAQAAAAAAAAA="},
+		{"5Q==", "5QAAAAAAAAAAAAAAAQAAAAAAAAA="},
+		{"aGVsbG8gd29ybGQ=", base64.StdEncoding.EncodeToString(referenceQuickXorHash([]byte("hello world")))},
+	}
+
+	for _, tt := range tests {
+		content, err := base64.StdEncoding.DecodeString(tt.content)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sum, err := QuickXorHash(bytes.NewReader(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := base64.StdEncoding.EncodeToString(sum); got != tt.want {
+			t.Errorf("QuickXorHash(%s) = %s, want %s", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestQuickXorHashReference(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	// lengths around the 160 bytes after which bit positions repeat
+	for _, n := range []int{1, 2, 7, 8, 19, 20, 21, 159, 160, 161, 319, 320, 1000, 4096, 65537} {
+		content := make([]byte, n)
+		rng.Read(content)
+		want := referenceQuickXorHash(content)
+
+		// the hash must not depend on how the content is split into writes
+		for _, split := range []int{1, 3, 64, n} {
+			h := newQuickXorHash()
+			for rest := content; len(rest) > 0; {
+				k := split
+				if k > len(rest) {
+					k = len(rest)
+				}
+				h.Write(rest[:k])
+				rest = rest[k:]
+			}
+
+			if got := h.Sum(nil); !bytes.Equal(got, want) {
+				t.Errorf("length %d in writes of %d: sum = %x, want %x", n, split, got, want)
+			}
+		}
+	}
+}
+
+func TestQuickXorHashSumAppends(t *testing.T) {
+	h := newQuickXorHash()
+	h.Write([]byte("hello"))
+
+	prefix := []byte("prefix")
+	got := h.Sum(append([]byte(nil), prefix...))
+	if !bytes.HasPrefix(got, prefix) || !bytes.Equal(got[len(prefix):], referenceQuickXorHash([]byte("hello"))) {
+		t.Errorf("Sum(prefix) = %x", got)
+	}
+
+	// Sum must not change the state
+	h.Write([]byte(" world"))
+	if got := h.Sum(nil); !bytes.Equal(got, referenceQuickXorHash([]byte("hello world"))) {
+		t.Errorf("sum after Sum and Write = %x", got)
+	}
+}
+
+func TestQuickXorHashFile(t *testing.T) {
+	content := []byte("The quick brown fox jumps over the lazy dog")
+	path := filepath.Join(t.TempDir(), "file.txt")
+	err := os.WriteFile(path, content, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := QuickXorHashFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := base64.StdEncoding.EncodeToString(referenceQuickXorHash(content)); got != want {
+		t.Errorf("QuickXorHashFile = %s, want %s", got, want)
+	}
+
+	_, err = QuickXorHashFile(filepath.Join(t.TempDir(), "missing"))
+	if !os.IsNotExist(err) {
+		t.Errorf("missing file: err = %v, want not exist", err)
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"net/http"
+
+	"golang.org/x/time/rate"
+)
+
+// WithRateLimiter limits the client to rps requests per second on average with
+// bursts of up to burst requests, pausing outgoing requests until they are allowed
+// instead of sending them only to be throttled with 429 Too Many Requests.
+// The limit is shared by all requests of the client, including token and upload requests.
+// The default, or an rps of zero or less, is no limit.
+//
+// Microsoft does not publish fixed limits for OneDrive. SharePoint and OneDrive
+// for Business throttle an application per tenant by resource units per minute, where
+// most requests cost 1 or 2 units and the budget grows with the number of licenses,
+// starting at 1,200 units per minute, i.e., about 10 requests per second.
+// An rps of 10 with a burst of 20 is a reasonable starting point for a single process.
+// See https://learn.microsoft.com/sharepoint/dev/general-development/how-to-avoid-getting-throttled-or-blocked-in-sharepoint-online.
+func WithRateLimiter(rps float64, burst int) Option {
+	return func(o *options) {
+		if rps <= 0 {
+			o.limiter = nil
+			return
+		}
+		if burst < 1 {
+			burst = 1
+		}
+		o.limiter = rate.NewLimiter(rate.Limit(rps), burst)
+	}
+}
+
+// rateLimitTransport waits for limiter before each request sent with base.
+type rateLimitTransport struct {
+	base    http.RoundTripper
+	limiter *rate.Limiter
+}
+
+// RoundTrip waits until limiter allows the request or its context is done, then sends it.
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	err := t.limiter.Wait(req.Context())
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	return t.base.RoundTrip(req)
+}
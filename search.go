@@ -0,0 +1,123 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// SearchHit is an item that matches a Graph search query.
+type SearchHit struct {
+	// The unique identifier of the hit.
+	HitId string `json:"hitId,omitempty"`
+
+	// The rank of the hit among the results, starting at 1.
+	Rank int `json:"rank,omitempty"`
+
+	// A summary of the result, with the matching terms highlighted.
+	Summary string `json:"summary,omitempty"`
+
+	// The matching entity, e.g., a DriveItem for the driveItem entity type.
+	Resource json.RawMessage `json:"resource,omitempty"`
+}
+
+// DriveItem decodes the Resource of a driveItem hit.
+func (h SearchHit) DriveItem() (driveItem DriveItem, err error) {
+	err = json.Unmarshal(h.Resource, &driveItem)
+
+	return driveItem, err
+}
+
+// SearchHitsContainer is a page of the hits of a search.
+type SearchHitsContainer struct {
+	// The hits of the page.
+	Hits []SearchHit `json:"hits,omitempty"`
+
+	// The total number of results. Not the number of hits in this page.
+	Total int `json:"total,omitempty"`
+
+	// Whether there are more results than in this page.
+	MoreResultsAvailable bool `json:"moreResultsAvailable,omitempty"`
+}
+
+// SearchResponse is the result of a Graph search.
+type SearchResponse struct {
+	// The terms of the query used for the search.
+	SearchTerms []string `json:"searchTerms,omitempty"`
+
+	// The hits, grouped by entity type.
+	HitsContainers []SearchHitsContainer `json:"hitsContainers,omitempty"`
+}
+
+// GraphSearchOptions controls GraphSearch.
+type GraphSearchOptions struct {
+	// The offset of the first hit to return, for paging.
+	From int
+
+	// The maximum number of hits to return. Defaults to Graph's default of 25.
+	Size int
+
+	// The properties of the resources to return, or all default properties if empty.
+	Fields []string
+
+	// The geographic region to search, e.g., NAM. Required for app-only authentication.
+	Region string
+}
+
+// GraphSearch runs query with the Microsoft Search API across entityTypes,
+// e.g., driveItem, which defaults if entityTypes is empty. Unlike the search of a drive,
+// it searches the content of OneDrive and SharePoint the user has access to.
+func (c *OneDriveClient) GraphSearch(ctx context.Context, query string, entityTypes []string, opts GraphSearchOptions) (SearchResponse, error) {
+	if len(entityTypes) == 0 {
+		entityTypes = []string{"driveItem"}
+	}
+
+	type searchQuery struct {
+		QueryString string `json:"queryString"`
+	}
+	type searchRequest struct {
+		EntityTypes []string    `json:"entityTypes"`
+		Query       searchQuery `json:"query"`
+		From        int         `json:"from,omitempty"`
+		Size        int         `json:"size,omitempty"`
+		Fields      []string    `json:"fields,omitempty"`
+		Region      string      `json:"region,omitempty"`
+	}
+	in := struct {
+		Requests []searchRequest `json:"requests"`
+	}{[]searchRequest{{
+		EntityTypes: entityTypes,
+		Query:       searchQuery{query},
+		From:        opts.From,
+		Size:        opts.Size,
+		Fields:      opts.Fields,
+		Region:      opts.Region,
+	}}}
+
+	var out struct {
+		Value []SearchResponse `json:"value"`
+	}
+	err := c.doJSON(ctx, http.MethodPost, c.buildURL("/search/query"), in, &out)
+	if err != nil || len(out.Value) == 0 {
+		return SearchResponse{}, err
+	}
+
+	return out.Value[0], nil
+}
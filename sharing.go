@@ -0,0 +1,376 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// SharingLinkType is the type of a sharing link.
+type SharingLinkType string
+
+const (
+	// LinkView is a read-only link.
+	LinkView SharingLinkType = "view"
+
+	// LinkEdit is a read-write link.
+	LinkEdit SharingLinkType = "edit"
+
+	// LinkEmbed is a read-only link to embed the item in a web page.
+	// Only supported by OneDrive Personal.
+	LinkEmbed SharingLinkType = "embed"
+)
+
+// SharingLink describes a sharing link of a permission.
+type SharingLink struct {
+	// The type of the link, e.g., view, edit, or embed.
+	Type SharingLinkType `json:"type,omitempty"`
+
+	// The scope of the link, e.g., anonymous, organization, or users.
+	Scope string `json:"scope,omitempty"`
+
+	// URL that opens the item in the browser.
+	WebURL string `json:"webUrl,omitempty"`
+
+	// For embed links, an HTML iframe that embeds the item in a web page.
+	WebHTML string `json:"webHtml,omitempty"`
+
+	// The app the link is associated with, if any.
+	Application *Identity `json:"application,omitempty"`
+}
+
+// DriveRecipient identifies a person or group to share an item with.
+type DriveRecipient struct {
+	// The email address of the recipient.
+	Email string `json:"email,omitempty"`
+
+	// The alias of the domain object, for cases where an email address is unavailable.
+	Alias string `json:"alias,omitempty"`
+
+	// The unique identifier of the recipient in the directory.
+	ObjectId string `json:"objectId,omitempty"`
+}
+
+// Permission describes the sharing permission granted for an item.
+type Permission struct {
+	// The unique identifier of the permission among all permissions on the item. Read-only.
+	Id string `json:"id,omitempty"`
+
+	// The type of permission, e.g., read, write, or owner.
+	Roles []string `json:"roles,omitempty"`
+
+	// The sharing link of the permission, for link permissions. Read-only.
+	Link *SharingLink `json:"link,omitempty"`
+
+	// The users and applications the permission is granted to. Read-only.
+	GrantedToIdentities []IdentitySet `json:"grantedToIdentities,omitempty"`
+
+	// The user or application the permission is granted to, for user permissions. Read-only.
+	GrantedTo *IdentitySet `json:"grantedTo,omitempty"`
+
+	// A unique token that can be used to access the item with the shares API. Read-only.
+	ShareId string `json:"shareId,omitempty"`
+
+	// When the permission expires, if it does.
+	ExpirationDateTime string `json:"expirationDateTime,omitempty"`
+
+	// Whether a password is set on the permission. Read-only.
+	HasPassword bool `json:"hasPassword,omitempty"`
+}
+
+// CreateSharingLinkOptions describes the sharing link created by CreateSharingLink.
+type CreateSharingLinkOptions struct {
+	// The type of the link. Defaults to LinkView.
+	Type SharingLinkType
+
+	// The scope of the link: anonymous, organization, or users.
+	// Defaults to the default of the organization or drive.
+	Scope string
+
+	// When the link expires, if not zero. It must be in the future and
+	// within MaxLinkExpiration, the longest expiration OneDrive allows for
+	// anonymous links. Administrators may set shorter limits, see
+	// https://learn.microsoft.com/en-us/sharepoint/turn-external-sharing-on-or-off
+	ExpirationDateTime time.Time
+
+	// Password that is required to open the link, if not empty.
+	// Only supported by OneDrive Personal.
+	Password string
+}
+
+// MaxLinkExpiration is the longest time until a sharing link can expire.
+const MaxLinkExpiration = 180 * 24 * time.Hour
+
+var (
+	// ErrInvalidExpiration is returned by CreateSharingLink for an expiration in the past.
+	ErrInvalidExpiration = errors.New("sharing link expiration is in the past")
+
+	// ErrExpirationTooFar is returned by CreateSharingLink for an expiration
+	// more than MaxLinkExpiration in the future.
+	ErrExpirationTooFar = errors.New("sharing link expiration is more than 180 days in the future")
+)
+
+// CreateSharingLink creates a sharing link for the item identified by itemID,
+// or returns the existing link of the same type and scope.
+// The expiration is validated before the request is made, since Graph rejects
+// an invalid expiration with an unspecific error.
+func (c *OneDriveClient) CreateSharingLink(ctx context.Context, itemID string, opts CreateSharingLinkOptions) (permission Permission, err error) {
+	if !opts.ExpirationDateTime.IsZero() {
+		now := time.Now()
+		if !opts.ExpirationDateTime.After(now) {
+			return Permission{}, ErrInvalidExpiration
+		}
+		if opts.ExpirationDateTime.Sub(now) > MaxLinkExpiration {
+			return Permission{}, fmt.Errorf("%w: %s is after %s", ErrExpirationTooFar,
+				opts.ExpirationDateTime.Format(time.RFC3339),
+				now.Add(MaxLinkExpiration).Format(time.RFC3339))
+		}
+	}
+
+	in := struct {
+		Type               SharingLinkType `json:"type"`
+		Scope              string          `json:"scope,omitempty"`
+		ExpirationDateTime string          `json:"expirationDateTime,omitempty"`
+		Password           string          `json:"password,omitempty"`
+	}{Type: opts.Type, Scope: opts.Scope, Password: opts.Password}
+	if in.Type == "" {
+		in.Type = LinkView
+	}
+	if !opts.ExpirationDateTime.IsZero() {
+		in.ExpirationDateTime = opts.ExpirationDateTime.UTC().Format(time.RFC3339)
+	}
+
+	err = c.doJSON(ctx, http.MethodPost,
+		c.driveURL("/items/"+url.PathEscape(itemID)+"/createLink"), in, &permission)
+
+	return permission, err
+}
+
+// GetItemSharingURL returns a URL of type linkType for item.
+//
+// For an item in a OneDrive Personal drive, the URL is derived from the WebURL
+// of item without a network call. Such a URL only opens the item for users
+// that already have access to it: view returns WebURL itself, and embed returns
+// the embed form of WebURL.
+//
+// Otherwise, e.g., for OneDrive for Business and SharePoint drives, where the
+// URL cannot be derived, or for an edit link, a sharing link is created or reused
+// with CreateSharingLink, which requires a network call and may grant access.
+func (c *OneDriveClient) GetItemSharingURL(ctx context.Context, item DriveItem, linkType SharingLinkType) (string, error) {
+	ref := item.ParentReference
+	if ref != nil && ref.DriveType == "personal" && item.WebURL != "" {
+		switch linkType {
+		case LinkView:
+			return item.WebURL, nil
+		case LinkEmbed:
+			if strings.Contains(item.WebURL, "/redir?") {
+				return strings.Replace(item.WebURL, "/redir?", "/embed?", 1), nil
+			}
+		}
+	}
+
+	if item.Id == "" {
+		return "", errors.New("item has no ID")
+	}
+
+	// the item may be in another drive, e.g., a shared item
+	client := c
+	if ref != nil && ref.DriveId != "" {
+		client = c.ForDrive(ref.DriveId)
+	}
+
+	permission, err := client.CreateSharingLink(ctx, item.Id, CreateSharingLinkOptions{Type: linkType})
+	if err != nil {
+		return "", err
+	}
+	if permission.Link == nil || permission.Link.WebURL == "" {
+		return "", errors.New("sharing link has no URL")
+	}
+
+	return permission.Link.WebURL, nil
+}
+
+// ListPermissions retrieves the sharing permissions of the item identified by itemID.
+func (c *OneDriveClient) ListPermissions(ctx context.Context, itemID string) (permissions []Permission, err error) {
+	body, err := c.get(ctx, c.driveURL("/items/"+url.PathEscape(itemID)+"/permissions"))
+	if err != nil {
+		return nil, err
+	}
+
+	var out struct {
+		Value []Permission `json:"value"`
+	}
+	err = c.unmarshal(body, &out)
+
+	return out.Value, err
+}
+
+// GetPermission retrieves the permission identified by permissionID of the item
+// identified by itemID, e.g., to check that a sharing link is still active.
+// A removed permission returns an error matching ErrNotFound, see IsNotFound.
+func (c *OneDriveClient) GetPermission(ctx context.Context, itemID, permissionID string) (permission Permission, err error) {
+	body, err := c.get(ctx, c.permissionURL(itemID, permissionID))
+	if err != nil {
+		return Permission{}, err
+	}
+
+	err = c.unmarshal(body, &permission)
+
+	return permission, err
+}
+
+// permissionURL returns the URL of the permission identified by permissionID of the item identified by itemID.
+func (c *OneDriveClient) permissionURL(itemID, permissionID string) string {
+	return c.driveURL("/items/" + url.PathEscape(itemID) + "/permissions/" + url.PathEscape(permissionID))
+}
+
+// ErrInvalidRole is returned by UpdatePermission for an unknown role.
+var ErrInvalidRole = errors.New("invalid permission role")
+
+// knownRoles are the roles of OneDrive and SharePoint permissions.
+var knownRoles = map[string]bool{
+	"read":               true,
+	"write":              true,
+	"owner":              true,
+	"sp.owner":           true,
+	"sp.member":          true,
+	"sp.full control":    true,
+	"sp.design":          true,
+	"sp.edit":            true,
+	"sp.contribute":      true,
+	"sp.read":            true,
+	"sp.view only":       true,
+	"sp.approve":         true,
+	"sp.restricted view": true,
+}
+
+// UpdatePermission replaces the roles of the permission identified by permissionID
+// of the item identified by itemID, e.g., with []string{"write"}, and returns the
+// updated permission. Unknown roles return ErrInvalidRole without a request.
+func (c *OneDriveClient) UpdatePermission(ctx context.Context, itemID, permissionID string, roles []string) (permission Permission, err error) {
+	if len(roles) == 0 {
+		return Permission{}, fmt.Errorf("%w: no roles", ErrInvalidRole)
+	}
+	for _, role := range roles {
+		if !knownRoles[role] {
+			return Permission{}, fmt.Errorf("%w: %q", ErrInvalidRole, role)
+		}
+	}
+
+	in := struct {
+		Roles []string `json:"roles"`
+	}{roles}
+
+	err = c.doJSON(ctx, http.MethodPatch, c.permissionURL(itemID, permissionID), in, &permission)
+
+	return permission, err
+}
+
+// MinLinkPasswordLength is the minimum length of the password of a sharing link.
+const MinLinkPasswordLength = 8
+
+// ErrPasswordTooShort is returned by SetSharingLinkPassword for a password
+// shorter than MinLinkPasswordLength characters.
+var ErrPasswordTooShort = errors.New("password too short")
+
+// SetSharingLinkPassword protects the sharing link of the permission identified by
+// permissionID of the item identified by itemID with password and returns the updated
+// permission. A password shorter than MinLinkPasswordLength characters returns
+// ErrPasswordTooShort without a request. Not every OneDrive plan supports passwords
+// on links; Graph returns 400 Bad Request when it does not.
+func (c *OneDriveClient) SetSharingLinkPassword(ctx context.Context, itemID, permissionID, password string) (permission Permission, err error) {
+	if utf8.RuneCountInString(password) < MinLinkPasswordLength {
+		return Permission{}, ErrPasswordTooShort
+	}
+
+	return c.setLinkPassword(ctx, itemID, permissionID, password)
+}
+
+// RemoveSharingLinkPassword removes the password from the sharing link of the permission
+// identified by permissionID of the item identified by itemID and returns the updated permission.
+func (c *OneDriveClient) RemoveSharingLinkPassword(ctx context.Context, itemID, permissionID string) (permission Permission, err error) {
+	return c.setLinkPassword(ctx, itemID, permissionID, "")
+}
+
+// setLinkPassword sets the password of the sharing link of a permission, or removes it if empty.
+func (c *OneDriveClient) setLinkPassword(ctx context.Context, itemID, permissionID, password string) (permission Permission, err error) {
+	in := struct {
+		Password string `json:"password"`
+	}{password}
+
+	err = c.doJSON(ctx, http.MethodPatch, c.permissionURL(itemID, permissionID), in, &permission)
+
+	return permission, err
+}
+
+// RevokeGrants revokes the access of grantees to the sharing link of the permission
+// identified by permissionID of the item identified by itemID, keeping the link valid
+// for others, and returns the updated permission. A permission that no longer exists
+// returns an error matching ErrNotFound, see IsNotFound.
+func (c *OneDriveClient) RevokeGrants(ctx context.Context, itemID, permissionID string, grantees []DriveRecipient) (permission Permission, err error) {
+	in := struct {
+		Grantees []DriveRecipient `json:"grantees"`
+	}{grantees}
+
+	err = c.doJSON(ctx, http.MethodPost,
+		c.permissionURL(itemID, permissionID)+"/revokeGrants", in, &permission)
+
+	return permission, err
+}
+
+// GetSharedLink resolves sharingURL, a sharing link or any URL of a shared item,
+// to the shared item using the shares API, including the permissions of the item.
+// An item in the drive of another user has the RemoteItem facet set.
+func (c *OneDriveClient) GetSharedLink(ctx context.Context, sharingURL string) (driveItem DriveItem, err error) {
+	body, err := c.get(ctx, c.buildURL("/shares/"+encodeSharingURL(sharingURL)+"/driveItem?$expand=permissions"))
+	if err != nil {
+		return DriveItem{}, err
+	}
+
+	err = c.unmarshal(body, &driveItem)
+
+	return driveItem, err
+}
+
+// GetItemByWebURL retrieves the item with webURL as its WebURL, e.g., copied from a browser,
+// using the shares API like GetSharedLink. Unlike a sharing link, the web URL of an item
+// grants no access, so the item must be accessible to the user.
+func (c *OneDriveClient) GetItemByWebURL(ctx context.Context, webURL string) (driveItem DriveItem, err error) {
+	body, err := c.get(ctx, c.buildURL("/shares/"+encodeSharingURL(webURL)+"/driveItem"))
+	if err != nil {
+		return DriveItem{}, err
+	}
+
+	err = c.unmarshal(body, &driveItem)
+
+	return driveItem, err
+}
+
+// encodeSharingURL encodes sharingURL as a sharing token for the shares API:
+// u! followed by the unpadded base64url encoding of the URL.
+func encodeSharingURL(sharingURL string) string {
+	return "u!" + base64.RawURLEncoding.EncodeToString([]byte(sharingURL))
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestGetItemByWebURL(t *testing.T) {
+	tests := []struct {
+		webURL  string
+		shareID string
+	}{
+		// example in the documentation of the shares API
+		{"https://onedrive.live.com/redir?resid=1231244193912!12&authKey=1201919!12921!1",
+			"u!aHR0cHM6Ly9vbmVkcml2ZS5saXZlLmNvbS9yZWRpcj9yZXNpZD0xMjMxMjQ0MTkzOTEyITEyJmF1dGhLZXk9MTIwMTkxOSExMjkyMSEx"},
+		// - in the encoding and padding removed
+		{"https://contoso-my.sharepoint.com/personal/user/Documents/a%20b.txt?web=1&x=>>?",
+			"u!aHR0cHM6Ly9jb250b3NvLW15LnNoYXJlcG9pbnQuY29tL3BlcnNvbmFsL3VzZXIvRG9jdW1lbnRzL2ElMjBiLnR4dD93ZWI9MSZ4PT4-Pw"},
+		{"https://1drv.ms/t/s!AbC~?", "u!aHR0cHM6Ly8xZHJ2Lm1zL3QvcyFBYkN-Pw"},
+	}
+
+	for _, tt := range tests {
+		var gotPath, gotQuery string
+		c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotPath, gotQuery = r.URL.Path, r.URL.RawQuery
+			fmt.Fprintf(w, `{"@odata.context":"x","id":"ITEM1","name":"a b.txt","webUrl":%q,"parentReference":{"driveId":"DRIVE1"},"file":{"mimeType":"text/plain"},"size":3}`, tt.webURL)
+		}))
+
+		item, err := c.GetItemByWebURL(context.Background(), tt.webURL)
+		if err != nil {
+			t.Errorf("GetItemByWebURL(%q): %v", tt.webURL, err)
+			continue
+		}
+
+		if want := "/v1.0/shares/" + tt.shareID + "/driveItem"; gotPath != want {
+			t.Errorf("GetItemByWebURL(%q) requested %q, want %q", tt.webURL, gotPath, want)
+		}
+		if gotQuery != "" {
+			t.Errorf("GetItemByWebURL(%q) sent query %q, want none", tt.webURL, gotQuery)
+		}
+		if item.Id != "ITEM1" || item.Name != "a b.txt" || item.WebURL != tt.webURL || item.Size != 3 ||
+			item.File == nil || item.File.MimeType != "text/plain" ||
+			item.ParentReference == nil || item.ParentReference.DriveId != "DRIVE1" {
+			t.Errorf("GetItemByWebURL(%q) = %+v, want decoded item", tt.webURL, item)
+		}
+	}
+}
+
+func TestGetItemByWebURLNotFound(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":{"code":"itemNotFound","message":"The sharing link no longer exists"}}`))
+	}))
+
+	_, err := c.GetItemByWebURL(context.Background(), "https://1drv.ms/t/s!gone")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("got error %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"net/url"
+	"sync"
+)
+
+// SpecialFolderName is the name of a special folder of a drive.
+type SpecialFolderName string
+
+const (
+	// SpecialDocuments is the Documents folder.
+	SpecialDocuments SpecialFolderName = "documents"
+
+	// SpecialPhotos is the Photos folder.
+	SpecialPhotos SpecialFolderName = "photos"
+
+	// SpecialCameraRoll is the Camera Roll Backup folder.
+	SpecialCameraRoll SpecialFolderName = "cameraroll"
+
+	// SpecialAppRoot is the personal folder of the application, created when first accessed.
+	SpecialAppRoot SpecialFolderName = "approot"
+
+	// SpecialMusic is the Music folder.
+	SpecialMusic SpecialFolderName = "music"
+)
+
+// specialFolderNames lists the documented special folders.
+var specialFolderNames = []SpecialFolderName{
+	SpecialDocuments,
+	SpecialPhotos,
+	SpecialCameraRoll,
+	SpecialAppRoot,
+	SpecialMusic,
+}
+
+// SpecialFolder is a special folder of a drive.
+type SpecialFolder struct {
+	Name SpecialFolderName
+
+	// False if the folder does not exist in the drive, such as Music or
+	// CameraRoll in some drives, in which case DriveItem is the zero value.
+	IsAvailable bool
+
+	DriveItem DriveItem
+}
+
+// GetSpecialFolder retrieves the special folder name of the drive.
+// An error satisfying IsNotFound is returned if the folder does not exist.
+func (c *OneDriveClient) GetSpecialFolder(ctx context.Context, name SpecialFolderName) (driveItem DriveItem, err error) {
+	body, err := c.get(ctx, c.driveURL("/special/"+url.PathEscape(string(name))))
+	if err != nil {
+		return DriveItem{}, err
+	}
+
+	err = c.unmarshal(body, &driveItem)
+
+	return driveItem, err
+}
+
+// ListSpecialFolders retrieves all documented special folders of the drive concurrently,
+// in the order of their constants. Folders that do not exist are returned with
+// IsAvailable false. Listing the folders creates the approot folder of the application.
+func (c *OneDriveClient) ListSpecialFolders(ctx context.Context) ([]SpecialFolder, error) {
+	folders := make([]SpecialFolder, len(specialFolderNames))
+	errs := make([]error, len(specialFolderNames))
+
+	var wg sync.WaitGroup
+	for i, name := range specialFolderNames {
+		wg.Add(1)
+		go func(i int, name SpecialFolderName) {
+			defer wg.Done()
+
+			item, err := c.GetSpecialFolder(ctx, name)
+			if err != nil && !IsNotFound(err) {
+				errs[i] = err
+				return
+			}
+			folders[i] = SpecialFolder{Name: name, IsAvailable: err == nil, DriveItem: item}
+		}(i, name)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return folders, nil
+}
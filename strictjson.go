@@ -0,0 +1,140 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrUnknownField is matched by the error returned for a response with a field
+// that the type it is decoded into does not have, see Config.StrictJSONParsing.
+var ErrUnknownField = errors.New("json: unknown field")
+
+// unmarshal decodes the response body into v. If the client parses strictly,
+// it first checks that every field of body is known to the type of v.
+func (c *OneDriveClient) unmarshal(body []byte, v interface{}) error {
+	if c.strictJSON {
+		var data interface{}
+		err := json.Unmarshal(body, &data)
+		if err != nil {
+			return err
+		}
+
+		err = checkFields(data, reflect.TypeOf(v), "")
+		if err != nil {
+			return err
+		}
+	}
+
+	return json.Unmarshal(body, v)
+}
+
+// checkFields returns an error for the first field of data, decoded JSON at path,
+// that has no matching field in t. Instance annotations, whose names start with @,
+// such as @odata.context or @microsoft.graph.downloadUrl, are ignored, since Graph
+// may add them to any object.
+// Unlike json.Decoder.DisallowUnknownFields, this checks the fields of types that
+// implement json.Unmarshaler by decoding into themselves, such as DriveItem.
+func checkFields(data interface{}, t reflect.Type, path string) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch data := data.(type) {
+	case map[string]interface{}:
+		switch t.Kind() {
+		case reflect.Map:
+			for key, value := range data {
+				err := checkFields(value, t.Elem(), path+"."+key)
+				if err != nil {
+					return err
+				}
+			}
+		case reflect.Struct:
+			fields := jsonFields(t)
+			for key, value := range data {
+				if strings.HasPrefix(key, "@") {
+					continue
+				}
+
+				ft, ok := fields[strings.ToLower(key)]
+				if !ok {
+					return fmt.Errorf("%w %q in %v", ErrUnknownField, strings.TrimPrefix(path+"."+key, "."), t)
+				}
+
+				err := checkFields(value, ft, path+"."+key)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i, value := range data {
+				err := checkFields(value, t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// jsonFields returns the types of the fields of struct t by lower case JSON name,
+// including the fields promoted from embedded structs.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			for embedded, et := range jsonFields(ft) {
+				if _, ok := fields[embedded]; !ok {
+					fields[embedded] = et
+				}
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		fields[strings.ToLower(name)] = f.Type
+	}
+
+	return fields
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalStrictAnnotations(t *testing.T) {
+	c := &OneDriveClient{strictJSON: true}
+
+	tests := []struct {
+		name string
+		body string
+		err  string // substring of the error, empty if none
+	}{
+		{"OData annotations", `{"@odata.context":"https://graph.microsoft.com/v1.0/$metadata#items/$entity","@odata.etag":"\"1\"","id":"1"}`, ""},
+		{"Graph annotation", `{"@microsoft.graph.downloadUrl":"https://example.invalid/download","id":"1"}`, ""},
+		{"unknown annotation", `{"@contoso.custom":{"a":1},"@":true,"id":"1"}`, ""},
+		{"annotation in facet", `{"id":"1","file":{"@odata.type":"#microsoft.graph.file","mimeType":"text/plain"}}`, ""},
+		{"annotation in list", `{"value":[{"@vendor.note":"x","id":"1"}]}`, ""},
+		{"unknown field", `{"@odata.context":"x","id":"1","unknownField":1}`, `"unknownField"`},
+		{"unknown field in facet", `{"id":"1","file":{"unknownFacet":true}}`, `"file.unknownFacet"`},
+		{"unknown field in list", `{"value":[{"id":"1"},{"bad":1}]}`, `"value[1].bad"`},
+		{"name containing @", `{"id":"1","name@odata.type":"x"}`, `"name@odata.type"`},
+	}
+
+	for _, tt := range tests {
+		var err error
+		if strings.HasPrefix(tt.body, `{"value"`) {
+			var v DriveItems
+			err = c.unmarshal([]byte(tt.body), &v)
+		} else {
+			var v DriveItem
+			err = c.unmarshal([]byte(tt.body), &v)
+		}
+
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		case tt.err != "" && !errors.Is(err, ErrUnknownField):
+			t.Errorf("%s: got error %v, want ErrUnknownField", tt.name, err)
+		case tt.err != "" && !strings.Contains(err.Error(), tt.err):
+			t.Errorf("%s: got error %q, want it to contain %s", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestUnmarshalNotStrict(t *testing.T) {
+	c := &OneDriveClient{}
+
+	var item DriveItem
+	err := c.unmarshal([]byte(`{"@contoso.custom":1,"id":"1","unknownField":1}`), &item)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if item.Id != "1" {
+		t.Errorf("got Id %q, want %q", item.Id, "1")
+	}
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// MaxSubscriptionLifetime is the longest time a subscription to drive items can last
+// before it must be renewed.
+const MaxSubscriptionLifetime = 42300 * time.Minute
+
+// Subscription is a subscription to change notifications for a resource, such as
+// /me/drive/root, which Graph sends to NotificationURL until the subscription expires.
+type Subscription struct {
+	// The unique identifier of the subscription. Read-only.
+	Id string `json:"id,omitempty"`
+
+	// The resource that is monitored, e.g., /me/drive/root.
+	Resource string `json:"resource,omitempty"`
+
+	// The changes to notify about; updated is the only change type for drive items.
+	ChangeType string `json:"changeType,omitempty"`
+
+	// URL of the endpoint that receives the notifications.
+	NotificationURL string `json:"notificationUrl,omitempty"`
+
+	// Value sent in each notification, so the endpoint can check that a notification
+	// comes from Graph, see ValidateWebhookNotification. Optional.
+	ClientState string `json:"clientState,omitempty"`
+
+	// Date and time in UTC when the subscription expires.
+	ExpirationDateTime string `json:"expirationDateTime,omitempty"`
+}
+
+// Expiration returns ExpirationDateTime as a time, or the zero time if it is not valid.
+func (s Subscription) Expiration() time.Time {
+	t, _ := time.Parse(time.RFC3339, s.ExpirationDateTime)
+	return t
+}
+
+// CreateSubscription creates the subscription described by sub and returns it.
+// Graph validates NotificationURL before creating the subscription.
+func (c *OneDriveClient) CreateSubscription(ctx context.Context, sub Subscription) (subscription Subscription, err error) {
+	err = c.doJSON(ctx, http.MethodPost, c.buildURL("/subscriptions"), sub, &subscription)
+
+	return subscription, err
+}
+
+// GetSubscription retrieves the subscription identified by subscriptionID.
+func (c *OneDriveClient) GetSubscription(ctx context.Context, subscriptionID string) (subscription Subscription, err error) {
+	body, err := c.get(ctx, c.subscriptionURL(subscriptionID))
+	if err != nil {
+		return Subscription{}, err
+	}
+
+	err = c.unmarshal(body, &subscription)
+
+	return subscription, err
+}
+
+// RenewSubscription extends the subscription identified by subscriptionID until
+// newExpiry, at most MaxSubscriptionLifetime from now, and returns the subscription.
+func (c *OneDriveClient) RenewSubscription(ctx context.Context, subscriptionID string, newExpiry time.Time) (subscription Subscription, err error) {
+	in := Subscription{ExpirationDateTime: newExpiry.UTC().Format(time.RFC3339)}
+
+	err = c.doJSON(ctx, http.MethodPatch, c.subscriptionURL(subscriptionID), in, &subscription)
+
+	return subscription, err
+}
+
+// subscriptionURL returns the URL of the subscription identified by subscriptionID.
+func (c *OneDriveClient) subscriptionURL(subscriptionID string) string {
+	return c.buildURL("/subscriptions/" + url.PathEscape(subscriptionID))
+}
+
+const (
+	// renewBefore is how long before expiry SubscriptionManager renews a subscription,
+	// unless its lifetime is shorter than twice that.
+	renewBefore = time.Hour
+
+	// renewRetry is how long SubscriptionManager waits after a failed renewal.
+	renewRetry = time.Minute
+)
+
+// SubscriptionManager renews subscriptions one hour before they expire, or halfway
+// through a shorter lifetime, so notifications do not stop when a subscription expires.
+type SubscriptionManager struct {
+	client      *OneDriveClient
+	lifetime    time.Duration
+	renewBefore time.Duration // how long before expiry to renew
+	ids         []string
+
+	// Optional. Called when a subscription cannot be retrieved or renewed;
+	// the renewal is retried a minute later. Must be safe to call from multiple goroutines.
+	OnError func(subscriptionID string, err error)
+}
+
+// NewSubscriptionManager creates a SubscriptionManager that extends the subscriptions
+// identified by subscriptionIDs by lifetime at each renewal. Zero or less, or more than
+// MaxSubscriptionLifetime, means MaxSubscriptionLifetime. A lifetime of less than
+// two hours is renewed halfway through, instead of one hour before it expires.
+func NewSubscriptionManager(client *OneDriveClient, lifetime time.Duration, subscriptionIDs ...string) *SubscriptionManager {
+	if lifetime <= 0 || lifetime > MaxSubscriptionLifetime {
+		lifetime = MaxSubscriptionLifetime
+	}
+
+	before := renewBefore
+	if lifetime < 2*renewBefore {
+		before = lifetime / 2
+	}
+
+	return &SubscriptionManager{client: client, lifetime: lifetime, renewBefore: before, ids: subscriptionIDs}
+}
+
+// Start renews the subscriptions in the background until ctx is done
+// or the returned function is called.
+func (m *SubscriptionManager) Start(ctx context.Context) context.CancelFunc {
+	ctx, cancel := context.WithCancel(ctx)
+
+	for _, id := range m.ids {
+		go m.keepAlive(ctx, id)
+	}
+
+	return cancel
+}
+
+// keepAlive renews the subscription identified by id before it expires until ctx is done.
+func (m *SubscriptionManager) keepAlive(ctx context.Context, id string) {
+	var (
+		known bool      // whether the subscription has been retrieved
+		next  time.Time // when to retrieve or renew the subscription
+	)
+
+	for {
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		var (
+			sub Subscription
+			err error
+		)
+		if known {
+			sub, err = m.client.RenewSubscription(ctx, id, time.Now().Add(m.lifetime))
+		} else {
+			sub, err = m.client.GetSubscription(ctx, id)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			if m.OnError != nil {
+				m.OnError(id, err)
+			}
+			next = time.Now().Add(renewRetry)
+			continue
+		}
+
+		known = true
+		next = sub.Expiration().Add(-m.renewBefore)
+		if sub.Expiration().IsZero() {
+			// unknown expiry, so check again later
+			next = time.Now().Add(renewRetry)
+		}
+	}
+}
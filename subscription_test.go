@@ -0,0 +1,49 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewSubscriptionManagerLifetime(t *testing.T) {
+	tests := []struct {
+		lifetime    time.Duration
+		want        time.Duration
+		renewBefore time.Duration
+	}{
+		{0, MaxSubscriptionLifetime, time.Hour},
+		{-time.Minute, MaxSubscriptionLifetime, time.Hour},
+		{30 * time.Minute, 30 * time.Minute, 15 * time.Minute},
+		{time.Hour, time.Hour, 30 * time.Minute},
+		{2*time.Hour - time.Second, 2*time.Hour - time.Second, time.Hour - time.Second/2},
+		{2 * time.Hour, 2 * time.Hour, time.Hour},
+		{3 * time.Hour, 3 * time.Hour, time.Hour},
+		{MaxSubscriptionLifetime, MaxSubscriptionLifetime, time.Hour},
+		{MaxSubscriptionLifetime + time.Second, MaxSubscriptionLifetime, time.Hour},
+	}
+
+	for _, tt := range tests {
+		m := NewSubscriptionManager(nil, tt.lifetime, "sub")
+		if m.lifetime != tt.want || m.renewBefore != tt.renewBefore {
+			t.Errorf("NewSubscriptionManager(%v) renews for %v, %v before expiry, want %v, %v before expiry",
+				tt.lifetime, m.lifetime, m.renewBefore, tt.want, tt.renewBefore)
+		}
+	}
+}
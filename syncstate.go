@@ -0,0 +1,69 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// SyncState is the state of an incremental sync that is persisted between runs,
+// so a sync can resume from the last delta link instead of reading the entire drive.
+type SyncState struct {
+	// The delta link returned by GetDelta for the next sync.
+	DeltaToken string `json:"deltaToken,omitempty"`
+
+	// When the last sync completed.
+	LastSyncTime time.Time `json:"lastSyncTime,omitempty"`
+
+	// QuickXorHash of the synced content of each file, by item ID.
+	ItemHashes map[string]string `json:"itemHashes,omitempty"`
+}
+
+// SaveSyncState writes state as json to path. If path already exists,
+// it is replaced atomically, so an interrupted save keeps the previous state.
+func SaveSyncState(path string, state SyncState) error {
+	return writeFileAtomic(path, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(state)
+	})
+}
+
+// LoadSyncState reads the state written by SaveSyncState from path.
+// If path does not exist, the error satisfies errors.Is(err, fs.ErrNotExist),
+// in which case a sync should start from the zero SyncState.
+func LoadSyncState(path string) (state SyncState, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return SyncState{}, err
+	}
+	defer file.Close()
+
+	err = json.NewDecoder(file).Decode(&state)
+	if err != nil {
+		return SyncState{}, fmt.Errorf("invalid sync state in %s: %w", path, err)
+	}
+
+	if state.ItemHashes == nil {
+		state.ItemHashes = make(map[string]string)
+	}
+
+	return state, nil
+}
@@ -0,0 +1,282 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// tokenRecorder is a TokenSource that remembers the last token from src,
+// so the current token can be inspected without network I/O.
+type tokenRecorder struct {
+	src oauth2.TokenSource
+
+	// onRefresh, if not nil, is called when src returns a new token
+	onRefresh func(newToken *oauth2.Token)
+
+	mu    sync.Mutex
+	token *oauth2.Token
+}
+
+// Token returns a token from src and records it.
+func (r *tokenRecorder) Token() (*oauth2.Token, error) {
+	token, err := r.src.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	refreshed := r.token != nil && r.token.AccessToken != token.AccessToken
+	r.token = token
+	r.mu.Unlock()
+
+	if refreshed && r.onRefresh != nil {
+		r.onRefresh(token)
+	}
+
+	return token, nil
+}
+
+// current returns the last recorded token, which may be nil.
+func (r *tokenRecorder) current() *oauth2.Token {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.token
+}
+
+// defaultLockTimeout is the default time to wait for the token file lock.
+const defaultLockTimeout = 30 * time.Second
+
+// initialFileToken reads the token from fileName, or if that fails, requests a
+// token from the user and saves it to fileName, all while holding the file lock.
+// The exchange of the authorization code for the token is limited to exchangeTimeout.
+func initialFileToken(ctx context.Context, conf *oauth2.Config, fileName string, lockTimeout, exchangeTimeout time.Duration) (*oauth2.Token, error) {
+	unlock, err := lockFile(fileName+".lock", lockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// try to get a usable token from the file
+	token, err := ReadTokenFromFile(fileName)
+	if err == nil {
+		err = validateToken(token)
+	}
+	if err == nil {
+		return token, nil
+	}
+
+	// could not get token from file, so ask the user
+	token, err = requestToken(ctx, conf, exchangeTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	// save the token to a file
+	err = WriteTokenToFile(fileName, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// ErrInvalidToken is returned for a token that cannot be used, such as a token
+// without an access token, which the token endpoint can return for incorrect scopes.
+var ErrInvalidToken = errors.New("invalid token")
+
+// validateToken checks that token has an access token that has not expired,
+// or if it has expired, that it can be refreshed.
+func validateToken(token *oauth2.Token) error {
+	switch {
+	case token == nil || token.AccessToken == "":
+		return fmt.Errorf("%w: no access token", ErrInvalidToken)
+	case !token.Expiry.IsZero() && token.Expiry.Before(time.Now()) && token.RefreshToken == "":
+		return fmt.Errorf("%w: expired at %v without a refresh token", ErrInvalidToken, token.Expiry)
+	}
+
+	return nil
+}
+
+// fileTokenSource refreshes the token while holding an advisory lock on the token file.
+// Azure AD only honors the latest refresh token, so processes sharing the file must
+// not refresh the same token concurrently. Under the lock, a token already refreshed
+// by another process is read from the file instead of being refreshed again.
+type fileTokenSource struct {
+	ctx         context.Context
+	conf        *oauth2.Config
+	fileName    string
+	lockTimeout time.Duration
+
+	mu    sync.Mutex
+	token *oauth2.Token
+}
+
+// Token returns a valid token from the file or by refreshing the current token.
+func (s *fileTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := lockFile(s.fileName+".lock", s.lockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// another process may have refreshed the token already
+	token, err := ReadTokenFromFile(s.fileName)
+	if err == nil && token.Valid() {
+		s.token = token
+		return token, nil
+	}
+
+	token, err = s.conf.TokenSource(s.ctx, s.token).Token()
+	if err != nil {
+		return nil, err
+	}
+
+	if token.AccessToken != s.token.AccessToken {
+		// share the refreshed token with other processes
+		err = WriteTokenToFile(s.fileName, token)
+		if err != nil {
+			return nil, err
+		}
+	}
+	s.token = token
+
+	return token, nil
+}
+
+// TokenExpiry returns the expiry of the current access token without network I/O.
+// The zero time is returned if there is no token yet or the token never expires.
+// An expired access token is refreshed automatically on the next request
+// if a refresh token is available.
+func (c *OneDriveClient) TokenExpiry() time.Time {
+	token := c.tokenSource.current()
+	if token == nil {
+		return time.Time{}
+	}
+
+	return token.Expiry
+}
+
+// GetAccessToken returns the current access token of the client, refreshing it first
+// if it has expired, so callers can make their own requests to Graph with it as a
+// bearer token. The token grants the access of the client to anyone who has it, so
+// keep it secret. It expires, see TokenExpiry; do not cache the returned string,
+// call GetAccessToken again for each request instead.
+func (c *OneDriveClient) GetAccessToken(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	token, err := c.tokenSource.Token()
+	if err != nil {
+		return "", err
+	}
+
+	return token.AccessToken, nil
+}
+
+// IsTokenValid reports whether the client can still authenticate with Graph.
+// It checks the expiry of the in-memory token and then confirms the token,
+// or the refresh token if the access token has expired, with GET /me?$select=id.
+// A rejected token reports false with a nil error; other failures return the error.
+// Since it uses /me, IsTokenValid requires delegated authentication.
+func (c *OneDriveClient) IsTokenValid(ctx context.Context) (bool, error) {
+	token := c.tokenSource.current()
+	if token != nil && !token.Valid() && token.RefreshToken == "" {
+		// expired and cannot be refreshed
+		return false, nil
+	}
+
+	_, err := c.get(ctx, c.buildURL("/me?$select=id"))
+	if err == nil {
+		return true, nil
+	}
+
+	// refresh token rejected by the token endpoint
+	var retrieveErr *oauth2.RetrieveError
+	if errors.As(err, &retrieveErr) {
+		return false, nil
+	}
+
+	// access token rejected by Graph
+	var respErr *RespError
+	if errors.As(err, &respErr) && respErr.Err != nil &&
+		respErr.Err.Code == "InvalidAuthenticationToken" {
+		return false, nil
+	}
+
+	return false, err
+}
+
+// TokenAge returns the time since token was issued, computed from its expiry and
+// the expires_in lifetime of the token response, or the ExpiresIn field of token,
+// which is kept by WriteTokenToFile. Zero is returned if the lifetime is unknown.
+func TokenAge(token *oauth2.Token) time.Duration {
+	if token == nil || token.Expiry.IsZero() {
+		return 0
+	}
+
+	var seconds float64
+	switch v := token.Extra("expires_in").(type) {
+	case float64:
+		seconds = v
+	case string:
+		seconds, _ = strconv.ParseFloat(v, 64)
+	}
+	if seconds <= 0 {
+		seconds = float64(token.ExpiresIn)
+	}
+	if seconds <= 0 {
+		return 0
+	}
+
+	issued := token.Expiry.Add(-time.Duration(seconds * float64(time.Second)))
+
+	return time.Since(issued)
+}
+
+// TokenFreshness returns the time until token expires; negative if it has expired
+// and zero for a nil token.
+// A token without an expiry never expires, so the maximum duration is returned.
+func TokenFreshness(token *oauth2.Token) time.Duration {
+	if token == nil {
+		return 0
+	}
+	if token.Expiry.IsZero() {
+		return math.MaxInt64
+	}
+
+	return time.Until(token.Expiry)
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// newTokenServer starts a token endpoint served by handler and returns a config that uses it.
+func newTokenServer(t *testing.T, handler http.HandlerFunc) *oauth2.Config {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return &oauth2.Config{
+		ClientID: "client",
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   server.URL + "/authorize",
+			TokenURL:  server.URL + "/token",
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+	}
+}
+
+func TestExchangeTokenTimeout(t *testing.T) {
+	const timeout = 100 * time.Millisecond
+
+	// a token endpoint that never responds
+	release := make(chan struct{})
+	conf := newTokenServer(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	t.Cleanup(func() { close(release) })
+
+	start := time.Now()
+	token, err := exchangeToken(context.Background(), conf, "code", timeout)
+	if elapsed := time.Since(start); elapsed > 10*timeout {
+		t.Errorf("exchangeToken returned after %v, want about %v", elapsed, timeout)
+	}
+	if err == nil || !strings.Contains(err.Error(), "token exchange timed out after 100ms") {
+		t.Errorf("err = %v, want token exchange timed out", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want it to wrap context.DeadlineExceeded", err)
+	}
+	if token != nil {
+		t.Errorf("token = %+v, want nil", token)
+	}
+}
+
+func TestExchangeToken(t *testing.T) {
+	conf := newTokenServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "code" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"access","token_type":"Bearer","refresh_token":"refresh","expires_in":3600}`))
+	})
+
+	token, err := exchangeToken(context.Background(), conf, "code", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "access" || token.RefreshToken != "refresh" {
+		t.Errorf("token = %+v", token)
+	}
+
+	// other errors are not reported as a timeout
+	_, err = exchangeToken(context.Background(), conf, "wrong", time.Second)
+	if err == nil || strings.Contains(err.Error(), "timed out") {
+		t.Errorf("err = %v, want the error of the token endpoint", err)
+	}
+}
+
+func TestTokenAge(t *testing.T) {
+	expiry := time.Now().Add(45 * time.Minute)
+	withExtra := func(expiresIn interface{}) *oauth2.Token {
+		return (&oauth2.Token{AccessToken: "token", Expiry: expiry}).
+			WithExtra(map[string]interface{}{"expires_in": expiresIn})
+	}
+
+	// the token as restored by ReadTokenFromFile, which keeps ExpiresIn but not Extra
+	fileName := filepath.Join(t.TempDir(), "token.json")
+	err := WriteTokenToFile(fileName, &oauth2.Token{AccessToken: "token", Expiry: expiry, ExpiresIn: 3600})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromFile, err := ReadTokenFromFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token *oauth2.Token
+		want  time.Duration // within a minute
+	}{
+		{"expires_in number", withExtra(3600.0), 15 * time.Minute},
+		{"expires_in string", withExtra("3600"), 15 * time.Minute},
+		{"ExpiresIn field", &oauth2.Token{Expiry: expiry, ExpiresIn: 3600}, 15 * time.Minute},
+		{"expires_in before ExpiresIn", (&oauth2.Token{Expiry: expiry, ExpiresIn: 3600}).
+			WithExtra(map[string]interface{}{"expires_in": 2700.0}), 0},
+		{"read from file", fromFile, 15 * time.Minute},
+		{"unknown lifetime", &oauth2.Token{Expiry: expiry}, 0},
+		{"no expiry", &oauth2.Token{ExpiresIn: 3600}, 0},
+		{"nil", nil, 0},
+	}
+
+	for _, tt := range tests {
+		got := TokenAge(tt.token)
+		if got < tt.want-time.Minute || got > tt.want+time.Minute {
+			t.Errorf("%s: TokenAge = %v, want about %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -17,12 +17,39 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>.
 
 package onedrive
 
+import "time"
+
 type Identity struct {
 	EMail       string `json:"email,omitempty"`
 	DisplayName string `json:"displayName,omitempty"`
 	Id          string `json:"id,omitempty"`
 }
 
+// User represents an Azure AD user account.
+type User struct {
+	// The unique identifier for the user. Read-only.
+	Id string `json:"id,omitempty"`
+
+	// The name displayed in the address book for the user.
+	DisplayName string `json:"displayName,omitempty"`
+
+	// The given name (first name) of the user.
+	GivenName string `json:"givenName,omitempty"`
+
+	// The user's surname (family name or last name).
+	Surname string `json:"surname,omitempty"`
+
+	// The SMTP address for the user.
+	Mail string `json:"mail,omitempty"`
+
+	// The user principal name (UPN) of the user, e.g. someuser@contoso.com.
+	UserPrincipalName string `json:"userPrincipalName,omitempty"`
+
+	// true if the account is enabled; otherwise, false.
+	// Only returned by Graph when explicitly selected.
+	AccountEnabled bool `json:"accountEnabled,omitempty"`
+}
+
 // using pointer to Identity so Unmarshal creates a nil on empty
 // (see https://stackoverflow.com/questions/33447334/golang-json-marshal-how-to-omit-empty-nested-struct)
 type IdentitySet struct {
@@ -108,6 +135,10 @@ type ParentReference struct {
 	DriveType string `json:"driveType,omitempty"`
 
 	Id string `json:"id,omitempty"`
+
+	// Path that can be used to navigate to the item, e.g., /drive/root:/Documents.
+	// Read-only. Not returned by delta.
+	Path string `json:"path,omitempty"`
 }
 
 type Package struct {
@@ -160,15 +191,86 @@ type File struct {
 	// This is determined by logic on the server and might not be
 	// the value provided when the file was uploaded. Read-only.
 	MimeType string `json:"mimeType,omitempty"`
+
+	// Hashes of the file's binary content, if available. Read-only.
+	Hashes *Hashes `json:"hashes,omitempty"`
 }
 
+// Hashes groups the available hashes of a file. Not every hash is available
+// for every item, e.g., OneDrive for Business only provides quickXorHash.
+type Hashes struct {
+	// The CRC32 value of the file in little endian (if available). Read-only.
+	Crc32Hash string `json:"crc32Hash,omitempty"`
+
+	// SHA1 hash for the contents of the file (if available). Read-only.
+	Sha1Hash string `json:"sha1Hash,omitempty"`
+
+	// SHA256 hash for the contents of the file (if available). Read-only.
+	Sha256Hash string `json:"sha256Hash,omitempty"`
+
+	// A proprietary hash of the file that can be used to determine
+	// if the contents of the file have changed (if available). Read-only.
+	QuickXorHash string `json:"quickXorHash,omitempty"`
+}
+
+type Folder struct {
+	// Number of children contained immediately within this container.
+	ChildCount int64 `json:"childCount,omitempty"`
+}
+
+// Image describes the dimensions of an image. Read-only.
+type Image struct {
+	// Height of the image, in pixels.
+	Height int `json:"height,omitempty"`
+
+	// Width of the image, in pixels.
+	Width int `json:"width,omitempty"`
+}
+
+// Deleted indicates that the item has been deleted.
+type Deleted struct {
+	// Represents the state of the deleted item.
+	State string `json:"state,omitempty"`
+}
+
+// PublicationFacet describes the published or checked out state of an item.
+type PublicationFacet struct {
+	// The state of publication for this document. Either published or checkout. Read-only.
+	Level string `json:"level,omitempty"`
+
+	// The unique identifier for the version that is visible to the current caller. Read-only.
+	VersionId string `json:"versionId,omitempty"`
+
+	// The user who checked out the file, if checked out. Read-only.
+	CheckedOutBy *IdentitySet `json:"checkedOutBy,omitempty"`
+}
+
+// Root indicates that the item is the top-most folder in the drive.
+type Root struct{}
+
+// SharepointIds identifies an item in SharePoint, so items in document libraries
+// can be correlated between the OneDrive and SharePoint APIs. Read-only.
 type SharepointIds struct {
-	ListId           string `json:"listId,omitempty"`
-	ListItemId       string `json:"listItemId,omitempty"`
+	// The unique identifier of the list.
+	ListId string `json:"listId,omitempty"`
+
+	// The integer identifier of the item within the list.
+	ListItemId string `json:"listItemId,omitempty"`
+
+	// The unique identifier of the item within the list.
 	ListItemUniqueId string `json:"listItemUniqueId,omitempty"`
-	SiteId           string `json:"siteId,omitempty"`
-	SiteUrl          string `json:"siteUrl,omitempty"`
-	WebId            string `json:"webId,omitempty"`
+
+	// The unique identifier of the site collection.
+	SiteId string `json:"siteId,omitempty"`
+
+	// The URL of the site.
+	SiteUrl string `json:"siteUrl,omitempty"`
+
+	// The unique identifier of the tenant.
+	TenantId string `json:"tenantId,omitempty"`
+
+	// The unique identifier of the site.
+	WebId string `json:"webId,omitempty"`
 }
 
 // DriveItem represents an item within a drive, like a document, photo, video, or folder.
@@ -188,6 +290,9 @@ type DriveItem struct {
 	// URL that displays the resource in the browser. Read-only.
 	WebURL string `json:"webUrl,omitempty"`
 
+	// User-visible description of the item. Read-write, see SetItemDescription.
+	Description string `json:"description,omitempty"`
+
 	// Size of the remote item. Read-only.
 	Size int64 `json:"size,omitempty"`
 
@@ -213,10 +318,50 @@ type DriveItem struct {
 	ParentReference *ParentReference `json:"parentReference,omitempty"`
 
 	SharepointIds *SharepointIds `json:"sharepointIds,omitempty"`
+
+	// Image metadata, if the item is an image. Read-only.
+	Image *Image `json:"image,omitempty"`
+
+	// Folder metadata, if the item is a folder. Read-only.
+	Folder *Folder `json:"folder,omitempty"`
+
+	// Information about the deleted state of the item. Read-only.
+	Deleted *Deleted `json:"deleted,omitempty"`
+
+	// If present, indicates that this is the root folder of the drive. Read-only.
+	Root *Root `json:"root,omitempty"`
+
+	// Permissions of the item, only present if expanded, e.g., by GetSharedLink. Read-only.
+	Permissions []Permission `json:"permissions,omitempty"`
+
+	// Publishing status of the item, for drives that support publishing,
+	// such as SharePoint document libraries. Read-only.
+	Publication *PublicationFacet `json:"publication,omitempty"`
+
+	// A short-lived URL to download the content of the file without authentication.
+	// Read-only. Use ContentURL, which reports an expired URL.
+	DownloadURL string `json:"@microsoft.graph.downloadUrl,omitempty"`
+
+	// when the item with DownloadURL was decoded
+	fetched time.Time
 }
 
 type DriveItems struct {
 	Value []DriveItem `json:"value"`
+
+	// URL of the next page of results, if any.
+	NextLink string `json:"@odata.nextLink,omitempty"`
+}
+
+// DeltaItems is a page of changes returned by the delta API.
+type DeltaItems struct {
+	Value []DriveItem `json:"value"`
+
+	// URL of the next page of changes in the current set, if any.
+	NextLink string `json:"@odata.nextLink,omitempty"`
+
+	// URL to request future changes, returned on the last page instead of NextLink.
+	DeltaLink string `json:"@odata.deltaLink,omitempty"`
 }
 
 type Drives struct {
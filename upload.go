@@ -0,0 +1,445 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+)
+
+// SmallFileMaxSize is the largest file that can be uploaded with UploadSmallFile.
+// Larger files must be uploaded with UploadLargeFile; UploadFile chooses automatically.
+const SmallFileMaxSize int64 = 4 * 1024 * 1024
+
+// chunkSizeMultiple is the size that upload session chunks must be a multiple of.
+const chunkSizeMultiple = 320 * 1024
+
+// DefaultChunkSize is the default size of the chunks uploaded by UploadLargeFile.
+const DefaultChunkSize = 16 * chunkSizeMultiple // 5 MiB
+
+// maxChunkSize is the largest chunk size below the 60 MiB limit of Graph.
+const maxChunkSize = 191 * chunkSizeMultiple
+
+// DefaultTargetChunkDuration is the default time an adaptive chunk should take to upload.
+const DefaultTargetChunkDuration = 30 * time.Second
+
+// ErrInvalidChunkSize is returned for a chunk size that is not a positive multiple of 320 KiB.
+var ErrInvalidChunkSize = errors.New("chunk size must be a positive multiple of 320 KiB")
+
+// ErrFileTooLarge is returned by UploadSmallFile for content larger than 4 MB.
+var ErrFileTooLarge = errors.New("file too large, use UploadLargeFile")
+
+// UploadSmallFile uploads the content of r, up to 4 MB, to the file at path relative
+// to the root of the drive and returns the file. An existing file is replaced.
+// If contentType is empty, it is detected from the name and content of the file,
+// see WithContentTypeDetector.
+// Use UploadLargeFile for larger files.
+func (c *OneDriveClient) UploadSmallFile(ctx context.Context, path string, r io.Reader, contentType string) (driveItem DriveItem, err error) {
+	// buffer the content, so the request has a Content-Length
+	content, err := io.ReadAll(io.LimitReader(r, SmallFileMaxSize+1))
+	if err != nil {
+		return DriveItem{}, err
+	}
+	if int64(len(content)) > SmallFileMaxSize {
+		return DriveItem{}, ErrFileTooLarge
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut,
+		c.driveURL(itemByPath(path)+"/content"), bytes.NewReader(content))
+	if err != nil {
+		return DriveItem{}, err
+	}
+	if contentType == "" {
+		contentType = c.contentType(path, content)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	var body bytes.Buffer
+	_, err = c.streamTo(req, &body)
+	if err != nil {
+		return DriveItem{}, err
+	}
+
+	err = c.unmarshal(body.Bytes(), &driveItem)
+
+	return driveItem, err
+}
+
+// sniffLen is the number of bytes considered by DetectContentType.
+const sniffLen = 512
+
+// DetectContentType returns the Content-Type of a file named name that starts with head,
+// using http.DetectContentType, or if the content is not recognized, the extension of name.
+// application/octet-stream is returned if neither is known.
+func DetectContentType(name string, head []byte) string {
+	if len(head) > sniffLen {
+		head = head[:sniffLen]
+	}
+
+	contentType := http.DetectContentType(head)
+	if contentType == "application/octet-stream" {
+		if byExt := mime.TypeByExtension(path.Ext(name)); byExt != "" {
+			contentType = byExt
+		}
+	}
+
+	return contentType
+}
+
+// contentType returns the Content-Type of content uploaded to name.
+func (c *OneDriveClient) contentType(name string, content []byte) string {
+	if len(content) > sniffLen {
+		content = content[:sniffLen]
+	}
+	if c.detectContentType != nil {
+		return c.detectContentType(name, content)
+	}
+
+	return DetectContentType(name, content)
+}
+
+// ErrSizeRequired is returned by UploadFile when the size of the content is unknown
+// and cannot be determined by seeking.
+var ErrSizeRequired = errors.New("size of the content is required")
+
+// UploadOptions controls UploadFile.
+type UploadOptions struct {
+	// Content-Type of a small file. If empty, it is detected, see UploadSmallFile.
+	// Graph determines the type of a large file itself.
+	ContentType string
+
+	// Set the file system info of the uploaded file to Created and Modified with
+	// SetFileSystemInfo, so the file keeps the times of the original instead of the
+	// time of the upload. If Modified is zero and r has a Stat method, such as an
+	// *os.File, the modification time of r is used.
+	PreserveTimestamps bool
+	Created            time.Time
+	Modified           time.Time
+
+	// Controls the upload session of a large file.
+	UploadLargeFileOptions
+}
+
+// UploadFile uploads size bytes from r to the file at path relative to the root of the
+// drive and returns the file, using UploadSmallFile for files up to SmallFileMaxSize
+// and UploadLargeFile for larger files. An existing file is replaced.
+// A negative size uploads the rest of r, determining its size by seeking to the end;
+// ErrSizeRequired is returned if r cannot seek.
+func (c *OneDriveClient) UploadFile(ctx context.Context, path string, r io.ReadSeeker, size int64, opts UploadOptions) (driveItem DriveItem, err error) {
+	if size < 0 {
+		size, err = remainingSize(r)
+		if err != nil {
+			return DriveItem{}, fmt.Errorf("%w: %v", ErrSizeRequired, err)
+		}
+	}
+
+	if opts.PreserveTimestamps && opts.Modified.IsZero() {
+		if f, ok := r.(interface{ Stat() (fs.FileInfo, error) }); ok {
+			if info, err := f.Stat(); err == nil {
+				opts.Modified = info.ModTime()
+			}
+		}
+	}
+
+	if size <= SmallFileMaxSize {
+		driveItem, err = c.UploadSmallFile(ctx, path, io.LimitReader(r, size), opts.ContentType)
+	} else {
+		driveItem, err = c.UploadLargeFile(ctx, path, r, size, opts.UploadLargeFileOptions)
+	}
+	if err != nil || !opts.PreserveTimestamps {
+		return driveItem, err
+	}
+
+	return c.SetFileSystemInfo(ctx, driveItem.Id, opts.Created, opts.Modified)
+}
+
+// SetFileSystemInfo sets the creation and modification times of the item identified by
+// itemID as recorded by the client file system, e.g., to the times of the original
+// file after an upload, and returns the updated item. A zero time is left unchanged.
+func (c *OneDriveClient) SetFileSystemInfo(ctx context.Context, itemID string, created, modified time.Time) (driveItem DriveItem, err error) {
+	var info FileSystemInfo
+	if !created.IsZero() {
+		info.CreatedDateTime = created.UTC().Format(time.RFC3339)
+	}
+	if !modified.IsZero() {
+		info.LastModifiedDateTime = modified.UTC().Format(time.RFC3339)
+	}
+	in := struct {
+		FileSystemInfo FileSystemInfo `json:"fileSystemInfo"`
+	}{info}
+
+	err = c.doJSON(ctx, http.MethodPatch, c.driveURL("/items/"+url.PathEscape(itemID)), in, &driveItem)
+
+	return driveItem, err
+}
+
+// remainingSize returns the number of bytes from the current offset of r to its end,
+// leaving the offset unchanged.
+func remainingSize(r io.Seeker) (int64, error) {
+	offset, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	_, err = r.Seek(offset, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+
+	return end - offset, nil
+}
+
+// UploadSession is a session to upload a large file in chunks.
+type UploadSession struct {
+	// Pre-authenticated URL to upload the chunks to.
+	UploadURL string `json:"uploadUrl,omitempty"`
+
+	// Date and time in UTC that the upload session will expire.
+	ExpirationDateTime string `json:"expirationDateTime,omitempty"`
+
+	// Ranges of the file that remain to be uploaded, e.g., 0- or 26-49.
+	NextExpectedRanges []string `json:"nextExpectedRanges,omitempty"`
+}
+
+// CreateUploadSession creates a session to upload the file at path relative
+// to the root of the drive. An existing file is replaced.
+func (c *OneDriveClient) CreateUploadSession(ctx context.Context, path string) (session UploadSession, err error) {
+	return c.createUploadSession(ctx, c.driveURL(itemByPath(path)))
+}
+
+// createUploadSession creates a session to upload the item at itemURL.
+func (c *OneDriveClient) createUploadSession(ctx context.Context, itemURL string) (session UploadSession, err error) {
+	in := map[string]interface{}{
+		"item": map[string]string{
+			"@microsoft.graph.conflictBehavior": "replace",
+		},
+	}
+
+	err = c.doJSON(ctx, http.MethodPost, itemURL+"/createUploadSession", in, &session)
+
+	return session, err
+}
+
+// UploadLargeFileOptions controls UploadLargeFile.
+//
+// Chunks are uploaded one at a time. Graph requires the chunks of an upload
+// session to be uploaded sequentially in order and rejects out of order chunks,
+// so a large file cannot be uploaded over concurrent connections.
+type UploadLargeFileOptions struct {
+	// Size of each uploaded chunk, a multiple of 320 KiB.
+	// Defaults to DefaultChunkSize. The upload of a chunk is only limited by the deadline
+	// of the context and by the client timeout on the wait for the response headers,
+	// see WithTimeout.
+	// With AdaptiveChunkSize, the size of the first chunk.
+	ChunkSize int64
+
+	// Adjust the size of each chunk to the measured speed of the previous chunk,
+	// so each chunk takes about TargetChunkDuration to upload. Larger chunks
+	// reduce the overhead on fast networks, smaller chunks reduce the cost of
+	// retransmission on slow networks. The size at most doubles from one chunk to the next.
+	AdaptiveChunkSize bool
+
+	// Limits of the adaptive chunk size, multiples of 320 KiB.
+	// Default to 320 KiB and the largest multiple below the 60 MiB limit of Graph.
+	MinChunkSize int64
+	MaxChunkSize int64
+
+	// Time each adaptive chunk should take to upload. Defaults to DefaultTargetChunkDuration.
+	// As for ChunkSize, the upload of a chunk is not limited by the client timeout, only the
+	// wait for the response headers is, so keep it well below the deadline of the context.
+	TargetChunkDuration time.Duration
+}
+
+// withDefaults returns opts with the defaults applied, or ErrInvalidChunkSize
+// if a chunk size is not a positive multiple of 320 KiB.
+func (opts UploadLargeFileOptions) withDefaults() (UploadLargeFileOptions, error) {
+	if opts.ChunkSize == 0 {
+		opts.ChunkSize = DefaultChunkSize
+	}
+	if opts.MinChunkSize == 0 {
+		opts.MinChunkSize = chunkSizeMultiple
+	}
+	if opts.MaxChunkSize == 0 {
+		opts.MaxChunkSize = maxChunkSize
+	}
+	if opts.TargetChunkDuration <= 0 {
+		opts.TargetChunkDuration = DefaultTargetChunkDuration
+	}
+
+	for _, n := range []int64{opts.ChunkSize, opts.MinChunkSize, opts.MaxChunkSize} {
+		if n < 0 || n%chunkSizeMultiple != 0 {
+			return opts, ErrInvalidChunkSize
+		}
+	}
+	if opts.AdaptiveChunkSize && opts.MinChunkSize > opts.MaxChunkSize {
+		return opts, ErrInvalidChunkSize
+	}
+
+	return opts, nil
+}
+
+// nextChunkSize returns the size of the chunk after a chunk of n bytes
+// that took elapsed to upload.
+func (opts UploadLargeFileOptions) nextChunkSize(n int64, elapsed time.Duration) int64 {
+	if !opts.AdaptiveChunkSize {
+		return opts.ChunkSize
+	}
+
+	next := 2 * n
+	if elapsed > 0 {
+		// bytes uploaded in the target time at the measured speed
+		target := float64(n) * float64(opts.TargetChunkDuration) / float64(elapsed)
+		if target < float64(next) {
+			next = int64(target)
+		}
+	}
+	next -= next % chunkSizeMultiple
+
+	if next < opts.MinChunkSize {
+		next = opts.MinChunkSize
+	}
+	if next > opts.MaxChunkSize {
+		next = opts.MaxChunkSize
+	}
+
+	return next
+}
+
+// UploadLargeFile uploads size bytes from r to the file at path relative to the
+// root of the drive in chunks using an upload session and returns the file.
+// An existing file is replaced. If the upload fails, the session is canceled.
+func (c *OneDriveClient) UploadLargeFile(ctx context.Context, path string, r io.Reader, size int64, opts UploadLargeFileOptions) (driveItem DriveItem, err error) {
+	return c.upload(ctx, c.driveURL(itemByPath(path)), r, size, opts)
+}
+
+// ErrItemCheckedOut is returned by UpdateItemContent for an item that is checked out.
+var ErrItemCheckedOut = errors.New("item is checked out")
+
+// UpdateItemContent replaces the content of the file identified by itemID with size
+// bytes from r using an upload session and returns the file. Unlike uploading to
+// the path of the file, the metadata and sharing links of the file are preserved.
+// ErrItemCheckedOut is returned if the file is checked out.
+func (c *OneDriveClient) UpdateItemContent(ctx context.Context, itemID string, r io.ReadSeeker, size int64) (driveItem DriveItem, err error) {
+	driveItem, err = c.upload(ctx, c.driveURL("/items/"+url.PathEscape(itemID)), r, size, UploadLargeFileOptions{})
+	if err == nil {
+		return driveItem, nil
+	}
+
+	// Graph reports a checked out item as a generic client error
+	switch code, _ := HTTPStatusCode(err); code {
+	case http.StatusBadRequest, http.StatusConflict, http.StatusLocked:
+		info, pubErr := c.GetPublicationInfo(ctx, itemID)
+		if pubErr == nil && (DriveItem{Publication: &info}).IsCheckedOut() {
+			return DriveItem{}, fmt.Errorf("%w: %v", ErrItemCheckedOut, err)
+		}
+	}
+
+	return DriveItem{}, err
+}
+
+// upload uploads size bytes from r to the item at itemURL in chunks using an upload session.
+func (c *OneDriveClient) upload(ctx context.Context, itemURL string, r io.Reader, size int64, opts UploadLargeFileOptions) (driveItem DriveItem, err error) {
+	opts, err = opts.withDefaults()
+	if err != nil {
+		return DriveItem{}, err
+	}
+	if size <= 0 {
+		return DriveItem{}, errors.New("size must be positive, use UploadSmallFile for empty files")
+	}
+
+	session, err := c.createUploadSession(ctx, itemURL)
+	if err != nil {
+		return DriveItem{}, err
+	}
+
+	driveItem, err = c.uploadChunks(ctx, session.UploadURL, r, size, opts)
+	if err != nil {
+		c.cancelUploadSession(session.UploadURL)
+		return DriveItem{}, err
+	}
+
+	return driveItem, nil
+}
+
+// uploadChunks uploads size bytes from r to uploadURL in chunks sized by opts
+// and returns the item created when the last chunk is uploaded.
+func (c *OneDriveClient) uploadChunks(ctx context.Context, uploadURL string, r io.Reader, size int64, opts UploadLargeFileOptions) (driveItem DriveItem, err error) {
+	var buf []byte
+
+	chunkSize := opts.ChunkSize
+	for start := int64(0); start < size; {
+		n := chunkSize
+		if size-start < n {
+			n = size - start
+		}
+		if int64(cap(buf)) < n {
+			buf = make([]byte, n)
+		}
+
+		_, err = io.ReadFull(r, buf[:n])
+		if err != nil {
+			return DriveItem{}, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, uploadURL, bytes.NewReader(buf[:n]))
+		if err != nil {
+			return DriveItem{}, err
+		}
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, start+n-1, size))
+
+		var body bytes.Buffer
+		sent := time.Now()
+		resp, err := c.sendVia(c.uploadClient, req, &body)
+		if err != nil {
+			return DriveItem{}, err
+		}
+
+		// the item is returned with the last chunk, the other chunks are accepted
+		if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated {
+			err = c.unmarshal(body.Bytes(), &driveItem)
+			return driveItem, err
+		}
+
+		start += n
+		chunkSize = opts.nextChunkSize(n, time.Since(sent))
+	}
+
+	return DriveItem{}, errors.New("upload session did not return the uploaded item")
+}
+
+// cancelUploadSession deletes the upload session at uploadURL, ignoring any error.
+func (c *OneDriveClient) cancelUploadSession(uploadURL string) {
+	req, err := http.NewRequest(http.MethodDelete, uploadURL, nil)
+	if err != nil {
+		return
+	}
+
+	c.sendVia(c.uploadClient, req, io.Discard)
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+)
+
+// pngHeader is the start of a PNG image: the signature and the IHDR chunk of a 1x1 image.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00\x1f\x15\xc4\x89")
+
+func TestDetectContentType(t *testing.T) {
+	tests := []struct {
+		name string
+		head []byte
+		want string
+	}{
+		{"image.png", pngHeader, "image/png"},
+		{"no-extension", pngHeader, "image/png"},
+		{"misnamed.txt", pngHeader, "image/png"},
+		{"notes.txt", []byte("plain text"), "text/plain; charset=utf-8"},
+		{"data.json", []byte{0, 1, 2, 3}, "application/json"},
+		{"data.unknown-extension", []byte{0, 1, 2, 3}, "application/octet-stream"},
+		{"empty", nil, "text/plain; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		if got := DetectContentType(tt.name, tt.head); got != tt.want {
+			t.Errorf("DetectContentType(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUploadSmallFileContentType(t *testing.T) {
+	var contentType string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"1","name":"uploaded"}`))
+	})
+	png := append(append([]byte(nil), pngHeader...), bytes.Repeat([]byte{0}, 1000)...)
+
+	tests := []struct {
+		name        string
+		opts        []Option
+		path        string
+		contentType string
+		want        string
+	}{
+		{"detected", nil, "/photo", "", "image/png"},
+		{"given", nil, "/photo.png", "application/x-custom", "application/x-custom"},
+		{"custom detector", []Option{WithContentTypeDetector(func(name string, head []byte) string {
+			if name != "/photo" || len(head) != sniffLen {
+				return "unexpected"
+			}
+			return "image/x-custom"
+		})}, "/photo", "", "image/x-custom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, handler, tt.opts...)
+
+			_, err := c.UploadSmallFile(context.Background(), tt.path, bytes.NewReader(png), tt.contentType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if contentType != tt.want {
+				t.Errorf("Content-Type = %q, want %q", contentType, tt.want)
+			}
+		})
+	}
+}
@@ -21,8 +21,13 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"log"
 	"os"
+	"path/filepath"
 
 	"golang.org/x/oauth2"
 )
@@ -42,10 +47,18 @@ func randomBytesBase64(n int) string {
 	return base64.URLEncoding.EncodeToString(b)
 }
 
-// readTokenFromFile reads the json encoded token from a file.
-func readTokenFromFile(filename string) (*oauth2.Token, error) {
+// ReadTokenFromFile reads the json encoded token from a file,
+// such as a file written by WriteTokenToFile.
+func ReadTokenFromFile(filename string) (*oauth2.Token, error) {
+	return ReadTokenFromFS(os.DirFS(filepath.Dir(filename)), filepath.Base(filename))
+}
+
+// ReadTokenFromFS reads the json encoded token from the file name in fsys,
+// such as an embedded file or an in-memory fstest.MapFS. If the file does not exist,
+// the error satisfies errors.Is(err, fs.ErrNotExist).
+func ReadTokenFromFS(fsys fs.FS, name string) (*oauth2.Token, error) {
 	// open file
-	file, err := os.Open(filename)
+	file, err := fsys.Open(name)
 	if err != nil {
 		return nil, err
 	}
@@ -54,22 +67,68 @@ func readTokenFromFile(filename string) (*oauth2.Token, error) {
 	// read json encoded token
 	token := &oauth2.Token{}
 	err = json.NewDecoder(file).Decode(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token in %s: %w", name, err)
+	}
+
+	return token, nil
+}
 
-	return token, err
+// WriteTokenToFile writes a json encoded token to a file.
+// If file already exists, it is replaced atomically, so a concurrent
+// ReadTokenFromFile never reads a partially written token.
+func WriteTokenToFile(fileName string, token *oauth2.Token) error {
+	return writeFileAtomic(fileName, func(w io.Writer) error {
+		// write access token string
+		return json.NewEncoder(w).Encode(token)
+	})
 }
 
-// writeTokenToFile writes a josn encoded token to a file.
-// If file already exists, it is replaced.
-func writeTokenToFile(fileName string, token *oauth2.Token) {
-	// create file
-	file, err := os.Create(fileName)
+// writeFileAtomic replaces fileName with the content written by write.
+// The content is written to a temporary file in the same directory,
+// which is then renamed to fileName, so fileName is never left partially written.
+func writeFileAtomic(fileName string, write func(w io.Writer) error) (err error) {
+	// create temporary file on the same file system as fileName
+	file, err := os.CreateTemp(filepath.Dir(fileName), filepath.Base(fileName)+".*.tmp")
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	defer file.Close()
+	defer func() {
+		if err != nil {
+			file.Close()
+			os.Remove(file.Name())
+		}
+	}()
 
-	// write access token string
-	json.NewEncoder(file).Encode(token)
+	err = write(file)
+	if err != nil {
+		return err
+	}
 
-	return
+	// flush to disk before the rename makes the content visible
+	err = file.Sync()
+	if err != nil {
+		return err
+	}
+
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), fileName)
+}
+
+// createTemp creates a new file named after fileName in the same directory, for writing,
+// to be renamed to fileName. Unlike os.CreateTemp, the file is created with perm before the umask.
+func createTemp(fileName string, perm os.FileMode) (*os.File, error) {
+	for i := 0; ; i++ {
+		name := fileName + "." + randomBytesBase64(6) + ".tmp"
+		file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+		if errors.Is(err, fs.ErrExist) && i < 100 {
+			continue
+		}
+
+		return file, err
+	}
 }
@@ -0,0 +1,169 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestWriteTokenToFile(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "token.json")
+	want := &oauth2.Token{
+		AccessToken:  "access",
+		TokenType:    "Bearer",
+		RefreshToken: "refresh",
+		Expiry:       time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	// replacing an existing token must work as well as creating the file
+	for i := 0; i < 2; i++ {
+		err := WriteTokenToFile(fileName, want)
+		if err != nil {
+			t.Fatalf("WriteTokenToFile: %v", err)
+		}
+	}
+
+	got, err := ReadTokenFromFile(fileName)
+	if err != nil {
+		t.Fatalf("ReadTokenFromFile: %v", err)
+	}
+	if got.AccessToken != want.AccessToken || got.RefreshToken != want.RefreshToken ||
+		!got.Expiry.Equal(want.Expiry) {
+		t.Errorf("ReadTokenFromFile = %+v, want %+v", got, want)
+	}
+
+	info, err := os.Stat(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm&0077 != 0 {
+		t.Errorf("token file mode = %v, want no access for group and others", perm)
+	}
+}
+
+// errCrash simulates a process that stops in the middle of writing a file.
+var errCrash = errors.New("simulated crash")
+
+// crashingWriter writes the first n bytes to w and then fails with errCrash.
+type crashingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (cw *crashingWriter) Write(p []byte) (int, error) {
+	if len(p) > cw.n {
+		written, _ := cw.w.Write(p[:cw.n])
+		cw.n -= written
+		return written, errCrash
+	}
+
+	written, err := cw.w.Write(p)
+	cw.n -= written
+	return written, err
+}
+
+func TestWriteFileAtomicFailure(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "token.json")
+	old := &oauth2.Token{AccessToken: "old", TokenType: "Bearer"}
+	err := WriteTokenToFile(fileName, old)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		n    int // bytes written before the crash
+	}{
+		{"before writing", 0},
+		{"mid-write", 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := writeFileAtomic(fileName, func(w io.Writer) error {
+				_, err := (&crashingWriter{w: w, n: tt.n}).Write([]byte(`{"access_token":"new","token_type":"Bearer"}`))
+				return err
+			})
+			if !errors.Is(err, errCrash) {
+				t.Fatalf("writeFileAtomic err = %v, want %v", err, errCrash)
+			}
+
+			// the previous token must still be readable
+			got, err := ReadTokenFromFile(fileName)
+			if err != nil {
+				t.Fatalf("ReadTokenFromFile after crash: %v", err)
+			}
+			if got.AccessToken != old.AccessToken {
+				t.Errorf("AccessToken = %q, want %q", got.AccessToken, old.AccessToken)
+			}
+
+			// and the temporary file must be removed
+			tmps, err := filepath.Glob(filepath.Join(dir, "*.tmp"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(tmps) != 0 {
+				t.Errorf("temporary files left: %v", tmps)
+			}
+		})
+	}
+}
+
+func TestReadTokenFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"token.json":   {Data: []byte(`{"access_token":"access","token_type":"Bearer","refresh_token":"refresh","expiry":"2030-01-02T03:04:05Z"}`)},
+		"corrupt.json": {Data: []byte(`{"access_token":"acc`)},
+		"empty.json":   {Data: []byte{}},
+		"text.json":    {Data: []byte("not a token")},
+	}
+
+	token, err := ReadTokenFromFS(fsys, "token.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "access" || token.RefreshToken != "refresh" ||
+		!token.Expiry.Equal(time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("token = %+v", token)
+	}
+
+	for _, name := range []string{"corrupt.json", "empty.json", "text.json"} {
+		token, err := ReadTokenFromFS(fsys, name)
+		if err == nil || !strings.Contains(err.Error(), "invalid token in "+name) {
+			t.Errorf("%s: err = %v, want invalid token", name, err)
+		}
+		if token != nil {
+			t.Errorf("%s: token = %+v, want nil", name, token)
+		}
+	}
+
+	_, err = ReadTokenFromFS(fsys, "missing.json")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing file: err = %v, want fs.ErrNotExist", err)
+	}
+}
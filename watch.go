@@ -0,0 +1,137 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"time"
+)
+
+// WatchEventType identifies the kind of WatchEvent.
+type WatchEventType int
+
+const (
+	// WatchCreated reports an item that was added to the folder.
+	WatchCreated WatchEventType = iota
+
+	// WatchModified reports an item whose eTag changed.
+	WatchModified
+
+	// WatchDeleted reports an item that was removed from the folder.
+	// Item is the item as it was last listed.
+	WatchDeleted
+
+	// WatchError reports that listing the folder failed. Err is the error.
+	WatchError
+)
+
+// WatchEvent is a change detected by Watch.
+type WatchEvent struct {
+	Type WatchEventType
+	Item DriveItem
+	Err  error
+}
+
+// Watch polls the children of the folder identified by folderID every interval and
+// reports each created, modified, or deleted child, detected by comparing the IDs and
+// eTags with the previous listing. The first listing is the baseline and is not reported.
+// Zero means DefaultDeltaPollInterval; shorter intervals are raised to MinDeltaPollInterval.
+// The channel is closed after ctx is done.
+//
+// Watch is meant for development; the delta API, see DeltaPoller, is more efficient.
+func Watch(ctx context.Context, client *OneDriveClient, folderID string, interval time.Duration) <-chan WatchEvent {
+	if interval == 0 {
+		interval = DefaultDeltaPollInterval
+	}
+	if interval < MinDeltaPollInterval {
+		interval = MinDeltaPollInterval
+	}
+
+	events := make(chan WatchEvent)
+
+	go func() {
+		defer close(events)
+
+		send := func(event WatchEvent) bool {
+			select {
+			case events <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		var previous map[string]DriveItem
+		for {
+			children, err := client.listAllChildren(ctx, folderID)
+			switch {
+			case err != nil:
+				if ctx.Err() != nil {
+					return
+				}
+				if !send(WatchEvent{Type: WatchError, Err: err}) {
+					return
+				}
+			default:
+				current := make(map[string]DriveItem, len(children))
+				for _, child := range children {
+					current[child.Id] = child
+				}
+
+				if previous != nil {
+					for _, event := range diffChildren(previous, current, children) {
+						if !send(event) {
+							return
+						}
+					}
+				}
+				previous = current
+			}
+
+			select {
+			case <-time.After(interval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events
+}
+
+// diffChildren returns the events that turn previous into current,
+// with created and modified items in the order of children.
+func diffChildren(previous, current map[string]DriveItem, children []DriveItem) (events []WatchEvent) {
+	for _, child := range children {
+		prev, ok := previous[child.Id]
+		switch {
+		case !ok:
+			events = append(events, WatchEvent{Type: WatchCreated, Item: child})
+		case prev.ETag != child.ETag:
+			events = append(events, WatchEvent{Type: WatchModified, Item: child})
+		}
+	}
+
+	for id, prev := range previous {
+		if _, ok := current[id]; !ok {
+			events = append(events, WatchEvent{Type: WatchDeleted, Item: prev})
+		}
+	}
+
+	return events
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2019 Bill Nixon
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published
+by the Free Software Foundation, either version 3 of the License,
+or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but
+WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package onedrive
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// ChangeNotification is a notification sent by Graph for a Subscription.
+// For drive items, a notification only reports that something changed below
+// Resource; use GetDelta to find out what changed.
+type ChangeNotification struct {
+	// The identifier of the subscription that generated the notification.
+	SubscriptionId string `json:"subscriptionId,omitempty"`
+
+	// The ClientState of the subscription.
+	ClientState string `json:"clientState,omitempty"`
+
+	// The type of change, e.g., updated.
+	ChangeType string `json:"changeType,omitempty"`
+
+	// The resource that changed, relative to the Graph endpoint.
+	Resource string `json:"resource,omitempty"`
+
+	// The expiration of the subscription.
+	SubscriptionExpirationDateTime string `json:"subscriptionExpirationDateTime,omitempty"`
+
+	// The identifier of the tenant of the subscription.
+	TenantId string `json:"tenantId,omitempty"`
+}
+
+// ErrInvalidClientState is returned by ValidateWebhookNotification for a notification
+// whose client state does not match, which may not have been sent by Graph.
+var ErrInvalidClientState = errors.New("invalid client state")
+
+// ValidateWebhookNotification checks that the client state of notification is
+// expectedClientState, the ClientState of the subscription, and returns
+// ErrInvalidClientState if it is not. The comparison takes constant time.
+func ValidateWebhookNotification(notification ChangeNotification, expectedClientState string) error {
+	if subtle.ConstantTimeCompare([]byte(notification.ClientState), []byte(expectedClientState)) != 1 {
+		return ErrInvalidClientState
+	}
+
+	return nil
+}
+
+// ParseWebhookBody decodes the notifications in the body of a webhook request,
+// either a batch of notifications in a value array, as Graph sends them,
+// or a single notification.
+func ParseWebhookBody(r io.Reader) ([]ChangeNotification, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var batch struct {
+		Value *[]ChangeNotification `json:"value"`
+	}
+	err = json.Unmarshal(body, &batch)
+	if err != nil {
+		return nil, err
+	}
+	if batch.Value != nil {
+		return *batch.Value, nil
+	}
+
+	var notification ChangeNotification
+	err = json.Unmarshal(body, &notification)
+	if err != nil {
+		return nil, err
+	}
+
+	return []ChangeNotification{notification}, nil
+}
+
+// maxWebhookBody limits the size of a webhook request body.
+const maxWebhookBody = 1 << 20
+
+// WrapNotificationHandler returns an http.Handler for the notification URL of subscriptions
+// with expectedClientState. It answers the validation request sent by Graph when a
+// subscription is created, and for notifications, calls handle with the notifications after
+// checking each with ValidateWebhookNotification. It responds with 202 Accepted if handle
+// returns nil, 400 Bad Request for an invalid body, 403 Forbidden for an invalid client state,
+// and 500 Internal Server Error if handle fails. Graph expects a response within a few
+// seconds, so handle should hand off long work instead of doing it.
+func WrapNotificationHandler(expectedClientState string, handle func(ctx context.Context, notifications []ChangeNotification) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// validation of the notification URL when the subscription is created
+		if token := r.URL.Query().Get("validationToken"); token != "" {
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusOK)
+			io.WriteString(w, token)
+			return
+		}
+
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		notifications, err := ParseWebhookBody(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+		if err != nil {
+			http.Error(w, "invalid notification", http.StatusBadRequest)
+			return
+		}
+		for _, notification := range notifications {
+			if ValidateWebhookNotification(notification, expectedClientState) != nil {
+				http.Error(w, "invalid client state", http.StatusForbidden)
+				return
+			}
+		}
+
+		if err := handle(r.Context(), notifications); err != nil {
+			http.Error(w, "notification not processed", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	})
+}